@@ -1,7 +1,12 @@
 package main
 
 import (
+	"MyFirstGoApp/internal/postgres"
 	"MyFirstGoApp/internal/server"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
 )
 
 func main() {
@@ -11,5 +16,67 @@ func main() {
 
 	//@host localhost:8080
 	//@BasePath /api/v1
+
+	//@securityDefinitions.apikey ApiKeyAuth
+	//@in header
+	//@name X-API-Key
+
+	//@securityDefinitions.apikey BearerAuth
+	//@in header
+	//@name Authorization
+	//@description JWT as "Bearer <token>"
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 	server.ServerRun()
 }
+
+// migrate runs the "migrate" subcommand:
+//
+//	migrate [up]        apply all pending migrations
+//	migrate down [N]    roll back the last N migrations, 1 by default
+//	migrate status      list migrations and when they were applied
+func migrate(args []string) error {
+	db, err := postgres.ConnectToDB(postgres.ConfigFromEnv())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		return postgres.MigrateUp(db)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		return postgres.MigrateDown(db, steps)
+	case "status":
+		statuses, err := postgres.MigrationsStatus(db)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, applied)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", command)
+	}
+}
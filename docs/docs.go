@@ -16,42 +16,1192 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/v1/admin/apikeys": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the issued API keys of the tenant, including revoked ones, without their secrets",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get API keys",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant of the keys, the tenant of the caller by default; other tenants need deployment:admin",
+                        "name": "tenant",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.APIKey"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates an API key with the scopes tasks:read, tasks:write, tasks:admin or deployment:admin for the tenant of the caller. Only a deployment admin may issue keys for another tenant, and no key may grant a scope the caller lacks. The key is returned only in this response.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Issue an API key",
+                "parameters": [
+                    {
+                        "description": "Name and scopes of the key",
+                        "name": "key",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.APIKey"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.IssuedAPIKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller may not issue the key",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/apikeys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revokes an API key of the tenant; requests with it are rejected from then on",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Revoke an API key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tenant of the key, the tenant of the caller by default; other tenants need deployment:admin",
+                        "name": "tenant",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Caller may not manage the keys of the tenant",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Active API key not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/breakers": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the circuit breakers of the destination hosts that have failed recently",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get circuit breakers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.BreakerState"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/quotas": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the queue-depth and worker-share quotas of the tenants; the * quota applies to the tenants without one of their own",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get tenant quotas",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.TenantQuota"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/quotas/{tenant}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Adds or replaces the quota of the tenant",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Set a tenant quota",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant, or * for the tenants without a quota of their own",
+                        "name": "tenant",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Quota, its tenant is taken from the path",
+                        "name": "quota",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.TenantQuota"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.TenantQuota"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes the quota of the tenant, which then gets the * quota",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Delete a tenant quota",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant, or *",
+                        "name": "tenant",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Quota not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/ratelimits": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the rate limits of outbound requests per destination host",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get outbound rate limits",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.RateLimit"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/ratelimits/{pattern}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Adds or replaces the rate limit of the hosts matching the pattern; waiting requests pick up the new limit",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Set an outbound rate limit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Host, *.domain or *",
+                        "name": "pattern",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Rate limit, its pattern is taken from the path",
+                        "name": "limit",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.RateLimit"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.RateLimit"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes the rate limit with the pattern",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Delete an outbound rate limit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Host, *.domain or *",
+                        "name": "pattern",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Rate limit not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/schedules": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns list of all recurring schedules",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Get all schedules",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Schedule"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates a schedule that creates a task from its template on every cron firing",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Create a recurring schedule",
+                "parameters": [
+                    {
+                        "description": "Schedule object",
+                        "name": "schedule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Schedule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/schedules/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns single schedule by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Get schedule by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Schedule"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces the cron expression, time zone, task template and enabled flag of a schedule",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Update schedule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Schedule object",
+                        "name": "schedule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Schedule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes schedule by ID, the tasks it created are kept",
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Delete schedule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Schedule not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/schedules/{id}/tasks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the tasks created by the schedule",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Get tasks of a schedule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Task"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/task": {
             "post": {
-                "description": "Creates a new HTTP task",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates a new HTTP task. A repeat of a request with the same Idempotency-Key returns the ID of the original task. With wait the response is held until the task is done, failed or cancelled, or the wait expires.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Create a new task and send it to a third party service",
+                "parameters": [
+                    {
+                        "description": "Task object",
+                        "name": "task",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Task"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key that makes a retried request return the original task",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "example": "30s",
+                        "description": "Time to wait for the task to finish, at most 60s; the task is returned instead of its ID",
+                        "name": "wait",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Task created by an earlier request with the same Idempotency-Key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Idempotency-Key reused with a different payload",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Queue-depth quota of the tenant exceeded",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Service is shutting down",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tasks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns one page of tasks. The X-Next-Cursor header holds the cursor of the next page and is missing on the last one.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Get tasks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated statuses to include",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "HTTP method",
+                        "name": "method",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host of the task URL",
+                        "name": "host",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Prefix of the task URL",
+                        "name": "url_prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Tags the tasks must all have",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Include tasks created at or after this RFC 3339 time",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Include tasks created before this RFC 3339 time",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "id",
+                        "description": "Sort order: id, created_at or priority, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Page size, at most 1000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "X-Next-Cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Task"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes all tasks from database",
+                "summary": "Delete all tasks",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tasks/batch": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Validates every task, stores the valid ones in one transaction and queues them. The result of each task is reported by its position in the batch.",
                 "consumes": [
+                    "application/json",
+                    "application/x-ndjson"
+                ],
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Create a batch of tasks",
+                "parameters": [
+                    {
+                        "description": "JSON array of tasks, or one task per line with Content-Type application/x-ndjson",
+                        "name": "tasks",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Task"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.BatchResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Batch is too large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Queue-depth quota of the tenant exceeded",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Service is shutting down",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tasks/events": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Streams Server-Sent Events: a \"status\" event every time a task changes its status and a \"response\" event when its response is stored. The data of an event is the task. A client that reconnects with Last-Event-ID gets the events it missed, as far as they are still buffered. After a restart of the service it gets a \"resync\" event instead, and has to reload the tasks.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Stream task events",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "IDs of the tasks to watch",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated statuses to include",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Tags the tasks must all have",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the last event received, to resume after a reconnect",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tasks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns single task by ID. With wait the response is held until the task is done, failed or cancelled, or the wait expires.",
                 "produces": [
                     "application/json"
                 ],
-                "tags": [
-                    "Tasks"
-                ],
-                "summary": "Create a new task and send it to a third party service",
+                "summary": "Get task by ID",
                 "parameters": [
                     {
-                        "description": "Task object",
-                        "name": "task",
-                        "in": "body",
-                        "required": true,
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "30s",
+                        "description": "Time to wait for the task to finish, at most 60s",
+                        "name": "wait",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/model.Task"
                         }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes task by ID",
+                "summary": "Delete task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "integer"
-                            }
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
-                    "400": {
-                        "description": "Bad request",
+                    "404": {
+                        "description": "Task not found",
                         "schema": {
                             "type": "string"
                         }
@@ -65,23 +1215,49 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/tasks": {
+        "/api/v1/tasks/{id}/attempts": {
             "get": {
-                "description": "Returns list of all tasks",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns every execution of a task with the request sent, the response or error and the duration",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get all tasks",
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Get attempt history of a task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/model.Task"
+                                "$ref": "#/definitions/model.TaskAttempt"
                             }
                         }
                     },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -89,13 +1265,50 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Deletes all tasks from database",
-                "summary": "Delete all tasks",
+            }
+        },
+        "/api/v1/tasks/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Cancels a queued, scheduled or running task; a running task has its request aborted",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Cancel task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Task"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Task has already finished",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
                     "500": {
                         "description": "Internal server error",
@@ -106,13 +1319,24 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/tasks/{id}": {
+        "/api/v1/tasks/{id}/deliveries": {
             "get": {
-                "description": "Returns single task by ID",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns every attempt to deliver the completion webhook of a task to its callback_url",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get task by ID",
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Get webhook deliveries of a task",
                 "parameters": [
                     {
                         "type": "integer",
@@ -126,7 +1350,10 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Task"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.WebhookDelivery"
+                            }
                         }
                     },
                     "404": {
@@ -142,10 +1369,26 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Deletes task by ID",
-                "summary": "Delete task",
+            }
+        },
+        "/api/v1/tasks/{id}/transitions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns every status change of a task with its time, starting with the status the task was created with",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Get status history of a task",
                 "parameters": [
                     {
                         "type": "integer",
@@ -156,8 +1399,14 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.TaskTransition"
+                            }
+                        }
                     },
                     "404": {
                         "description": "Task not found",
@@ -176,8 +1425,710 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "model.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "@Description Time the key was issued",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@Description API key ID",
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "@Description Name of the key, such as the service that uses it",
+                    "type": "string",
+                    "example": "billing-service"
+                },
+                "prefix": {
+                    "description": "@Description First characters of the key, to recognize it",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "description": "@Description Time the key was revoked",
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "@Description Permissions of the key: tasks:read, tasks:write, tasks:admin, deployment:admin",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "tasks:read",
+                        "tasks:write"
+                    ]
+                },
+                "tenant": {
+                    "description": "@Description Tenant whose tasks the key works with, the tenant of the issuing key by default",
+                    "type": "string",
+                    "example": "team-a"
+                }
+            }
+        },
+        "model.AssertionResult": {
+            "type": "object",
+            "properties": {
+                "assertion": {
+                    "description": "@Description The checked assertion, such as status, header Content-Type, body_regex or json_path $.data.id",
+                    "type": "string"
+                },
+                "message": {
+                    "description": "@Description Why the assertion failed",
+                    "type": "string"
+                },
+                "passed": {
+                    "description": "@Description Whether the response passed the assertion",
+                    "type": "boolean"
+                }
+            }
+        },
+        "model.Assertions": {
+            "type": "object",
+            "properties": {
+                "body_regex": {
+                    "description": "@Description Regular expression the response body has to match",
+                    "type": "string",
+                    "example": "^OK$"
+                },
+                "headers": {
+                    "description": "@Description Required response headers; an empty value only requires the header to be present",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "json_path": {
+                    "description": "@Description Values the JSON response body has to contain",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.JSONPathAssertion"
+                    }
+                },
+                "status": {
+                    "description": "@Description Accepted status codes: exact codes such as 201, classes such as 2xx or ranges such as 200-299",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "2xx"
+                    ]
+                }
+            }
+        },
+        "model.AttemptRequest": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "description": "@Description Request body",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.Body"
+                        }
+                    ]
+                },
+                "headers": {
+                    "description": "@Description HTTP headers",
+                    "type": "object"
+                },
+                "method": {
+                    "description": "@Description HTTP method",
+                    "type": "string"
+                },
+                "url": {
+                    "description": "@Description Target URL",
+                    "type": "string"
+                }
+            }
+        },
+        "model.BatchResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "@Description Validation error of the task",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@Description ID of the created task",
+                    "type": "integer"
+                },
+                "index": {
+                    "description": "@Description Position of the task in the batch",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Body": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "description": "@Description Body content: a string for text and base64, any JSON value for json",
+                    "type": "object"
+                },
+                "content_type": {
+                    "description": "@Description Content-Type of the body, defaults depend on the body type",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "@Description Body type: text, json or base64",
+                    "type": "string"
+                }
+            }
+        },
+        "model.BreakerState": {
+            "type": "object",
+            "properties": {
+                "failures": {
+                    "description": "@Description Consecutive failed requests",
+                    "type": "integer"
+                },
+                "host": {
+                    "description": "@Description Destination host",
+                    "type": "string"
+                },
+                "opened_at": {
+                    "description": "@Description Time the circuit was opened",
+                    "type": "string"
+                },
+                "retry_at": {
+                    "description": "@Description Time the next request is let through to probe the host",
+                    "type": "string"
+                },
+                "state": {
+                    "description": "@Description Circuit state: closed, open or half_open",
+                    "type": "string"
+                }
+            }
+        },
+        "model.IssuedAPIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "@Description Time the key was issued",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@Description API key ID",
+                    "type": "integer"
+                },
+                "key": {
+                    "description": "@Description The key to send in the X-API-Key header, shown only once",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "@Description Name of the key, such as the service that uses it",
+                    "type": "string",
+                    "example": "billing-service"
+                },
+                "prefix": {
+                    "description": "@Description First characters of the key, to recognize it",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "description": "@Description Time the key was revoked",
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "@Description Permissions of the key: tasks:read, tasks:write, tasks:admin, deployment:admin",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "tasks:read",
+                        "tasks:write"
+                    ]
+                },
+                "tenant": {
+                    "description": "@Description Tenant whose tasks the key works with, the tenant of the issuing key by default",
+                    "type": "string",
+                    "example": "team-a"
+                }
+            }
+        },
+        "model.JSONPathAssertion": {
+            "type": "object",
+            "properties": {
+                "equals": {
+                    "description": "@Description Expected value at the path",
+                    "type": "object"
+                },
+                "path": {
+                    "description": "@Description Path into the JSON body such as $.data.items[0].id",
+                    "type": "string",
+                    "example": "$.data.id"
+                }
+            }
+        },
+        "model.RateLimit": {
+            "type": "object",
+            "properties": {
+                "burst": {
+                    "description": "@Description Requests that can be sent at once before the rate applies, defaults to the rate rounded up",
+                    "type": "integer",
+                    "example": 20
+                },
+                "max_concurrent": {
+                    "description": "@Description Requests in flight to each matching host at the same time, unlimited when 0",
+                    "type": "integer",
+                    "example": 5
+                },
+                "pattern": {
+                    "description": "@Description Host the limit applies to: an exact host, *.domain for its subdomains or * for every host",
+                    "type": "string",
+                    "example": "*.partner.com"
+                },
+                "requests_per_second": {
+                    "description": "@Description Requests per second to each matching host, unlimited when 0",
+                    "type": "number",
+                    "example": 10
+                }
+            }
+        },
+        "model.RequestOptions": {
+            "type": "object",
+            "properties": {
+                "connect_timeout": {
+                    "description": "@Description Time limit for establishing the connection",
+                    "type": "string",
+                    "example": "5s"
+                },
+                "max_redirects": {
+                    "description": "@Description Maximum number of redirects to follow, 10 by default",
+                    "type": "integer"
+                },
+                "redirects": {
+                    "description": "@Description Redirect policy: follow (default) or none",
+                    "type": "string"
+                },
+                "timeout": {
+                    "description": "@Description Total time limit of the request, 10s by default",
+                    "type": "string",
+                    "example": "30s"
+                },
+                "tls": {
+                    "description": "@Description TLS certificate verification: verify (default) or skip",
+                    "type": "string"
+                }
+            }
+        },
+        "model.ResponseData": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "content_length": {
+                    "type": "integer"
+                },
+                "headers": {
+                    "type": "object"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.RetryPolicy": {
+            "type": "object",
+            "properties": {
+                "base_delay": {
+                    "description": "@Description Delay before the first retry, doubled on every next one",
+                    "type": "string",
+                    "example": "1s"
+                },
+                "jitter": {
+                    "description": "@Description Fraction of the delay that is randomized, from 0 to 1",
+                    "type": "number"
+                },
+                "max_attempts": {
+                    "description": "@Description Maximum number of attempts including the first one",
+                    "type": "integer"
+                },
+                "max_delay": {
+                    "description": "@Description Upper bound for the delay between retries",
+                    "type": "string",
+                    "example": "1m"
+                },
+                "retry_on_errors": {
+                    "description": "@Description Network errors that are retried: timeout, connection_refused, connection_reset, dns, tls; defaults to all of them",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "retry_on_status": {
+                    "description": "@Description Response status codes that are retried, defaults to 429, 500, 502, 503 and 504",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "model.Schedule": {
+            "type": "object",
+            "properties": {
+                "cron": {
+                    "description": "@Description Cron expression with five fields or a descriptor such as @hourly",
+                    "type": "string",
+                    "example": "*/5 * * * *"
+                },
+                "enabled": {
+                    "description": "@Description Whether the schedule fires",
+                    "type": "boolean"
+                },
+                "id": {
+                    "description": "@Description Schedule ID",
+                    "type": "integer"
+                },
+                "last_error": {
+                    "description": "@Description Why the last firing created no task, empty when it did",
+                    "type": "string"
+                },
+                "last_run_at": {
+                    "description": "@Description Time of the last firing",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "@Description Schedule name",
+                    "type": "string"
+                },
+                "next_run_at": {
+                    "description": "@Description Time of the next firing",
+                    "type": "string"
+                },
+                "task": {
+                    "description": "@Description Template of the task created on every firing",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.Task"
+                        }
+                    ]
+                },
+                "tenant": {
+                    "description": "@Description Tenant the schedule and its tasks belong to, taken from the credentials of the request",
+                    "type": "string",
+                    "example": "team-a"
+                },
+                "timezone": {
+                    "description": "@Description IANA time zone the cron expression is evaluated in, UTC by default",
+                    "type": "string",
+                    "example": "Europe/Moscow"
+                }
+            }
+        },
         "model.Task": {
-            "type": "object"
+            "type": "object",
+            "properties": {
+                "assertion_results": {
+                    "description": "@Description Outcome of each assertion for the last response",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AssertionResult"
+                    }
+                },
+                "assertions": {
+                    "description": "@Description Checks of the response that decide between done and error, any response is done when omitted",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.Assertions"
+                        }
+                    ]
+                },
+                "attempts": {
+                    "description": "@Description Number of attempts made so far",
+                    "type": "integer"
+                },
+                "body": {
+                    "description": "@Description Request body",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.Body"
+                        }
+                    ]
+                },
+                "callback_url": {
+                    "description": "@Description URL the final task is POSTed to when it is done, failed or cancelled",
+                    "type": "string",
+                    "example": "https://example.com/hooks/tasks"
+                },
+                "created_at": {
+                    "description": "@Description Time the task was created",
+                    "type": "string"
+                },
+                "created_by": {
+                    "description": "@Description Subject of the API key (apikey:\u003cid\u003e) or token (jwt:\u003csub\u003e) that created the task, set by the server",
+                    "type": "string",
+                    "example": "apikey:2"
+                },
+                "delay": {
+                    "description": "@Description Delay before the task is sent, an alternative to run_at",
+                    "type": "string",
+                    "example": "10m"
+                },
+                "duration": {
+                    "description": "@Description Time between started_at and finished_at",
+                    "type": "string",
+                    "example": "1.5s"
+                },
+                "finished_at": {
+                    "description": "@Description Time the task was done, failed or cancelled",
+                    "type": "string"
+                },
+                "headers": {
+                    "description": "@Description HTTP headers",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "description": "@Description Task ID",
+                    "type": "integer"
+                },
+                "idempotency_key": {
+                    "description": "@Description Idempotency-Key header of the request that created the task",
+                    "type": "string"
+                },
+                "lease_expires_at": {
+                    "description": "@Description Time the task is taken back from its owner unless the owner renews the lease",
+                    "type": "string"
+                },
+                "lease_owner": {
+                    "description": "@Description Instance that last worked on the task",
+                    "type": "string"
+                },
+                "method": {
+                    "description": "@Description HTTP method",
+                    "type": "string"
+                },
+                "next_retry_at": {
+                    "description": "@Description Time of the next retry",
+                    "type": "string"
+                },
+                "options": {
+                    "description": "@Description Options of the outbound request",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.RequestOptions"
+                        }
+                    ]
+                },
+                "priority": {
+                    "description": "@Description Priority in the queue from -1000 to 1000, tasks with a higher priority are sent first",
+                    "type": "integer"
+                },
+                "queued_at": {
+                    "description": "@Description Time the task last entered the queue",
+                    "type": "string"
+                },
+                "response": {
+                    "description": "@Description HTTP response",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ResponseData"
+                        }
+                    ]
+                },
+                "retry": {
+                    "description": "@Description Retry policy, the task is sent once when omitted",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.RetryPolicy"
+                        }
+                    ]
+                },
+                "run_at": {
+                    "description": "@Description Time to send the task at, the task is sent right away when omitted",
+                    "type": "string"
+                },
+                "schedule_id": {
+                    "description": "@Description ID of the recurring schedule that created the task",
+                    "type": "integer"
+                },
+                "started_at": {
+                    "description": "@Description Time the first attempt of the task started",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "@Description Task status",
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "@Description Labels to filter the task list and the event stream by",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "billing"
+                    ]
+                },
+                "tenant": {
+                    "description": "@Description Tenant the task belongs to, taken from the credentials of the request",
+                    "type": "string",
+                    "example": "team-a"
+                },
+                "url": {
+                    "description": "@Description Target URL",
+                    "type": "string"
+                }
+            }
+        },
+        "model.TaskAttempt": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "description": "@Description Number of the attempt, starting at 1",
+                    "type": "integer"
+                },
+                "duration": {
+                    "description": "@Description Time between started_at and finished_at",
+                    "type": "string",
+                    "example": "250ms"
+                },
+                "error": {
+                    "description": "@Description Error of a request that got no response",
+                    "type": "string"
+                },
+                "finished_at": {
+                    "description": "@Description Time the response or error arrived",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@Description Attempt ID",
+                    "type": "integer"
+                },
+                "request": {
+                    "description": "@Description The request as it was sent",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.AttemptRequest"
+                        }
+                    ]
+                },
+                "response": {
+                    "description": "@Description The response, missing when the request failed",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ResponseData"
+                        }
+                    ]
+                },
+                "started_at": {
+                    "description": "@Description Time the request was sent",
+                    "type": "string"
+                },
+                "task_id": {
+                    "description": "@Description ID of the task",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.TaskTransition": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "description": "@Description Time of the transition",
+                    "type": "string"
+                },
+                "from": {
+                    "description": "@Description Status before the transition, missing when the task was created",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@Description Transition ID",
+                    "type": "integer"
+                },
+                "task_id": {
+                    "description": "@Description ID of the task",
+                    "type": "integer"
+                },
+                "to": {
+                    "description": "@Description Status after the transition",
+                    "type": "string"
+                }
+            }
+        },
+        "model.TenantQuota": {
+            "type": "object",
+            "properties": {
+                "max_queued": {
+                    "description": "@Description Unfinished tasks the tenant may have; creating more is refused with 429, unlimited when 0",
+                    "type": "integer",
+                    "example": 10000
+                },
+                "tenant": {
+                    "description": "@Description Tenant the quota applies to, or * for the tenants without a quota of their own",
+                    "type": "string",
+                    "example": "team-a"
+                },
+                "worker_share": {
+                    "description": "@Description Fraction of the workers that may send the tenant's tasks at once, from 0 to 1, unlimited when 0",
+                    "type": "number",
+                    "example": 0.5
+                }
+            }
+        },
+        "model.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "description": "@Description Number of the delivery attempt, starting at 1",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "description": "@Description Time of the attempt",
+                    "type": "string"
+                },
+                "error": {
+                    "description": "@Description Error of a failed delivery",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@Description Delivery ID",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "@Description Task status the webhook reported",
+                    "type": "string"
+                },
+                "status_code": {
+                    "description": "@Description Response status code of the callback",
+                    "type": "integer"
+                },
+                "success": {
+                    "description": "@Description Whether the callback answered with a 2xx status",
+                    "type": "boolean"
+                },
+                "task_id": {
+                    "description": "@Description ID of the task the webhook was sent for",
+                    "type": "integer"
+                },
+                "url": {
+                    "description": "@Description Callback URL the webhook was sent to",
+                    "type": "string"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "JWT as \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
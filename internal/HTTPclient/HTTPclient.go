@@ -4,42 +4,144 @@ import (
 	"MyFirstGoApp/internal/client"
 	"MyFirstGoApp/internal/model"
 	"bytes"
+	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"sync"
 	"time"
 )
 
+const (
+	DefaultTimeout      = 10 * time.Second
+	DefaultMaxRedirects = 10
+)
+
 func NewClient() client.Client {
+	return NewClientWithOptions(nil)
+}
+
+// NewClientWithOptions builds a client for the request options of a task.
+// Unset options keep the defaults of NewClient.
+func NewClientWithOptions(options *model.RequestOptions) client.Client {
+	return NewClientWithBreakers(options, nil)
+}
+
+// NewClientWithBreakers builds a client like NewClientWithOptions that does
+// not send requests to hosts whose circuit in breakers is open.
+func NewClientWithBreakers(options *model.RequestOptions, breakers *Breakers) client.Client {
+	if options == nil {
+		options = &model.RequestOptions{}
+	}
+
+	timeout := time.Duration(options.Timeout)
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	maxRedirects := options.MaxRedirects
+	if maxRedirects == 0 {
+		maxRedirects = DefaultMaxRedirects
+	}
+	checkRedirect := func(req *http.Request, via []*http.Request) error {
+		if options.Redirects == model.RedirectNone {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return nil
+	}
+
 	return &HTTPclient{
 		client: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout:       timeout,
+			Transport:     transportFor(transportKey{time.Duration(options.ConnectTimeout), options.TLS == model.TLSSkip}),
+			CheckRedirect: checkRedirect,
 		},
+		breakers: breakers,
+	}
+}
+
+type transportKey struct {
+	connectTimeout     time.Duration
+	insecureSkipVerify bool
+}
+
+// transports caches one transport per combination of connection options, so
+// that clients built for different tasks still reuse connections.
+var transports sync.Map
+
+func transportFor(key transportKey) http.RoundTripper {
+	if transport, ok := transports.Load(key); ok {
+		return transport.(http.RoundTripper)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if key.connectTimeout > 0 {
+		dialer := &net.Dialer{
+			Timeout:   key.connectTimeout,
+			KeepAlive: 30 * time.Second,
+		}
+		transport.DialContext = dialer.DialContext
+		transport.TLSHandshakeTimeout = key.connectTimeout
 	}
+	if key.insecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	actual, _ := transports.LoadOrStore(key, transport)
+	return actual.(http.RoundTripper)
 }
 
 type HTTPclient struct {
-	client *http.Client
+	client   *http.Client
+	breakers *Breakers
 }
 
-func (c *HTTPclient) SendTask(task *model.Task) (*model.ResponseData, error) {
+func (c *HTTPclient) SendTask(ctx context.Context, task *model.Task) (*model.ResponseData, error) {
 
-	req, err := http.NewRequest(task.Method, task.URL, bytes.NewBuffer(nil))
+	payload, err := task.Body.Bytes()
+	if err != nil {
+		log.Println("Request body error: ", err)
+		return nil, fmt.Errorf("request body error: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, task.Method, task.URL, bytes.NewBuffer(payload))
 	if err != nil {
 		log.Println("Request creation error: ", err)
 		return nil, fmt.Errorf("request creation error: %w", err)
 	}
 
-	for key, value := range task.Headers {
-		req.Header.Set(key, value)
+	req.Header = task.RequestHeaders()
+
+	record := func(result outcome) {}
+	if c.breakers != nil {
+		record, err = c.breakers.allow(req.URL.Hostname())
+		if err != nil {
+			log.Println("Request not sent:", err)
+			return nil, err
+		}
 	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
+		if ctx.Err() != nil {
+			record(ignored)
+		} else {
+			record(failed)
+		}
 		log.Println("Request sending error:", err)
 		return nil, fmt.Errorf("request sending error: %w", err)
 	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		record(failed)
+	} else {
+		record(succeeded)
+	}
 	defer resp.Body.Close()
 	log.Printf("Third-party response for task with ID %d: %v\n", task.ID, resp)
 
@@ -2,10 +2,14 @@ package HTTPclient
 
 import (
 	"MyFirstGoApp/internal/model"
+	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestSendTask_Success(t *testing.T) {
@@ -39,7 +43,7 @@ func TestSendTask_Success(t *testing.T) {
 		Status: model.New,
 	}
 
-	resp, err := client.SendTask(task)
+	resp, err := client.SendTask(context.Background(), task)
 
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -70,7 +74,7 @@ func TestSendTask_Error(t *testing.T) {
 		URL:    server.URL,
 		Status: model.New,
 	}
-	resp, err := client.SendTask(task)
+	resp, err := client.SendTask(context.Background(), task)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -91,7 +95,7 @@ func TestSendTask_InvalidURL(t *testing.T) {
 		URL:    "http://invalid-url-that-does-not-exist.example",
 		Status: model.New,
 	}
-	resp, err := client.SendTask(task)
+	resp, err := client.SendTask(context.Background(), task)
 	if err == nil {
 		t.Fatal("Expected error for invalid URL, got nil")
 	}
@@ -113,7 +117,7 @@ func TestSendTask_Timeout(t *testing.T) {
 		URL:    server.URL,
 		Status: model.New,
 	}
-	resp, err := client.SendTask(task)
+	resp, err := client.SendTask(context.Background(), task)
 	t.Logf("Response: %+v, Error: %v", resp, err)
 }
 
@@ -138,7 +142,7 @@ func TestSendTask_DifferentMethods(t *testing.T) {
 				URL:    server.URL,
 				Status: model.New,
 			}
-			resp, err := client.SendTask(task)
+			resp, err := client.SendTask(context.Background(), task)
 			if err != nil {
 				t.Fatalf("Expected no error for %s, got %v", method, err)
 			}
@@ -175,7 +179,7 @@ func TestSendTask_WithRequestBody(t *testing.T) {
 		},
 		Status: model.New,
 	}
-	resp, err := client.SendTask(task)
+	resp, err := client.SendTask(context.Background(), task)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -187,3 +191,152 @@ func TestSendTask_WithRequestBody(t *testing.T) {
 		t.Errorf("Expected body %s, got %s", expectedBody, resp.Body)
 	}
 }
+
+func TestSendTask_BodyTypes(t *testing.T) {
+	testCases := []struct {
+		name                string
+		body                *model.Body
+		expectedPayload     string
+		expectedContentType string
+	}{
+		{
+			name:                "text",
+			body:                &model.Body{Type: model.BodyText, Content: json.RawMessage(`"hello"`)},
+			expectedPayload:     "hello",
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:                "json",
+			body:                &model.Body{Type: model.BodyJSON, Content: json.RawMessage(`{"name":"test"}`)},
+			expectedPayload:     `{"name":"test"}`,
+			expectedContentType: "application/json",
+		},
+		{
+			name:                "base64",
+			body:                &model.Body{Type: model.BodyBase64, ContentType: "image/png", Content: json.RawMessage(`"AAEC"`)},
+			expectedPayload:     "\x00\x01\x02",
+			expectedContentType: "image/png",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				payload, _ := io.ReadAll(r.Body)
+				if string(payload) != tc.expectedPayload {
+					t.Errorf("Expected payload %q, got %q", tc.expectedPayload, string(payload))
+				}
+				if r.Header.Get("Content-Type") != tc.expectedContentType {
+					t.Errorf("Expected Content-Type: %s, got %s", tc.expectedContentType, r.Header.Get("Content-Type"))
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+			client := NewClient()
+			task := &model.Task{
+				ID:     11,
+				Method: "PUT",
+				URL:    server.URL,
+				Body:   tc.body,
+				Status: model.New,
+			}
+			if _, err := client.SendTask(context.Background(), task); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSendTask_InvalidBody(t *testing.T) {
+	client := NewClient()
+	task := &model.Task{
+		ID:     12,
+		Method: "POST",
+		URL:    "http://localhost",
+		Body:   &model.Body{Type: model.BodyBase64, Content: json.RawMessage(`"not base64!"`)},
+		Status: model.New,
+	}
+	resp, err := client.SendTask(context.Background(), task)
+	if err == nil {
+		t.Fatal("Expected error for invalid body, got nil")
+	}
+	if resp != nil {
+		t.Errorf("Expected nil response, got %+v", resp)
+	}
+}
+
+func TestSendTask_RedirectOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hops, _ := strconv.Atoi(r.URL.Query().Get("hops"))
+		if hops > 0 {
+			http.Redirect(w, r, "/?hops="+strconv.Itoa(hops-1), http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name           string
+		options        *model.RequestOptions
+		expectedStatus int
+		expectError    bool
+	}{
+		{"Follow", nil, http.StatusOK, false},
+		{"None", &model.RequestOptions{Redirects: model.RedirectNone}, http.StatusFound, false},
+		{"MaxRedirects", &model.RequestOptions{MaxRedirects: 2}, 0, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewClientWithOptions(tc.options)
+			task := &model.Task{ID: 13, Method: "GET", URL: server.URL + "/?hops=3", Options: tc.options}
+			resp, err := client.SendTask(context.Background(), task)
+			if tc.expectError {
+				if err == nil {
+					t.Fatal("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tc.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestSendTask_TimeoutOption(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(&model.RequestOptions{Timeout: model.Duration(20 * time.Millisecond)})
+	_, err := client.SendTask(context.Background(), &model.Task{ID: 14, Method: "GET", URL: server.URL})
+	if err == nil {
+		t.Fatal("Expected timeout error, got nil")
+	}
+}
+
+func TestSendTask_TLSOption(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	task := &model.Task{ID: 15, Method: "GET", URL: server.URL}
+
+	if _, err := NewClient().SendTask(context.Background(), task); err == nil {
+		t.Error("Expected certificate error with verification enabled, got nil")
+	}
+
+	client := NewClientWithOptions(&model.RequestOptions{TLS: model.TLSSkip})
+	resp, err := client.SendTask(context.Background(), task)
+	if err != nil {
+		t.Fatalf("Expected no error with verification skipped, got %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+}
@@ -0,0 +1,183 @@
+package HTTPclient
+
+import (
+	"MyFirstGoApp/internal/model"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// Actions for a task whose host has an open circuit.
+const (
+	OpenDefer = "defer"
+	OpenFail  = "fail"
+)
+
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// circuit of a host.
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before requests probe
+	// the host again.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of probe requests let through at once
+	// while the circuit is half-open.
+	HalfOpenRequests int
+	// OpenAction is what happens to a task whose host has an open circuit:
+	// OpenDefer delays it until the circuit is probed, OpenFail fails it.
+	OpenAction string
+}
+
+var DefaultBreakerConfig = BreakerConfig{
+	FailureThreshold: 5,
+	OpenTimeout:      30 * time.Second,
+	HalfOpenRequests: 1,
+	OpenAction:       OpenDefer,
+}
+
+// CircuitOpenError is returned instead of sending a request to a host whose
+// circuit is open.
+type CircuitOpenError struct {
+	Host    string
+	RetryAt time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit of host %s is open until %s", e.Host, e.RetryAt.Format(time.RFC3339))
+}
+
+// Breakers keeps a circuit breaker per destination host. A request that fails
+// with a network error or a 5xx status counts as a failure.
+type Breakers struct {
+	config BreakerConfig
+	mu     sync.Mutex
+	hosts  map[string]*breaker
+}
+
+type breaker struct {
+	state    string
+	failures int
+	openedAt time.Time
+	probes   int
+	active   int
+}
+
+func NewBreakers(config BreakerConfig) *Breakers {
+	return &Breakers{
+		config: config,
+		hosts:  make(map[string]*breaker),
+	}
+}
+
+func (b *Breakers) Config() BreakerConfig {
+	return b.config
+}
+
+// outcome is the result of a request as seen by the breaker of its host.
+type outcome int
+
+const (
+	succeeded outcome = iota
+	failed
+	// ignored is the outcome of a request that says nothing about the host,
+	// such as a cancelled one.
+	ignored
+)
+
+// allow reports whether a request to host may be sent. The returned function
+// records the outcome of the request and has to be called once.
+func (b *Breakers) allow(host string) (func(result outcome), error) {
+	host = strings.ToLower(host)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br, ok := b.hosts[host]
+	if !ok {
+		br = &breaker{state: CircuitClosed}
+		b.hosts[host] = br
+	}
+
+	now := time.Now()
+	if br.state == CircuitOpen {
+		retryAt := br.openedAt.Add(b.config.OpenTimeout)
+		if now.Before(retryAt) {
+			return nil, &CircuitOpenError{Host: host, RetryAt: retryAt}
+		}
+		br.state = CircuitHalfOpen
+		br.probes = 0
+	}
+	probe := br.state == CircuitHalfOpen
+	if probe {
+		if br.probes >= max(b.config.HalfOpenRequests, 1) {
+			return nil, &CircuitOpenError{Host: host, RetryAt: now.Add(b.config.OpenTimeout)}
+		}
+		br.probes++
+	}
+	br.active++
+
+	var once sync.Once
+	return func(result outcome) {
+		once.Do(func() {
+			b.record(host, br, probe, result)
+		})
+	}, nil
+}
+
+func (b *Breakers) record(host string, br *breaker, probe bool, result outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br.active--
+	if probe {
+		br.probes--
+	}
+	switch result {
+	case succeeded:
+		br.state = CircuitClosed
+		br.failures = 0
+	case failed:
+		br.failures++
+		if br.state == CircuitHalfOpen || br.failures >= b.config.FailureThreshold {
+			br.state = CircuitOpen
+			br.openedAt = time.Now()
+		}
+	}
+	// Healthy hosts are not kept.
+	if br.state == CircuitClosed && br.failures == 0 && br.active == 0 {
+		delete(b.hosts, host)
+	}
+}
+
+// States returns the breakers of the hosts that have failed recently, ordered
+// by host.
+func (b *Breakers) States() []model.BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	states := make([]model.BreakerState, 0, len(b.hosts))
+	for host, br := range b.hosts {
+		if br.state == CircuitClosed && br.failures == 0 {
+			continue
+		}
+		state := model.BreakerState{Host: host, State: br.state, Failures: br.failures}
+		if br.state != CircuitClosed {
+			openedAt := br.openedAt
+			retryAt := br.openedAt.Add(b.config.OpenTimeout)
+			state.OpenedAt = &openedAt
+			state.RetryAt = &retryAt
+		}
+		states = append(states, state)
+	}
+	slices.SortFunc(states, func(x, y model.BreakerState) int {
+		return strings.Compare(x.Host, y.Host)
+	})
+	return states
+}
@@ -0,0 +1,94 @@
+package HTTPclient
+
+import (
+	"MyFirstGoApp/internal/model"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBreakerOpensAfterFailures(t *testing.T) {
+	breakers := NewBreakers(BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute, HalfOpenRequests: 1})
+	for i := 0; i < 2; i++ {
+		record, err := breakers.allow("example.com")
+		if err != nil {
+			t.Fatalf("Expected request %d to be allowed, got %v", i, err)
+		}
+		record(failed)
+	}
+
+	_, err := breakers.allow("EXAMPLE.com")
+	var open *CircuitOpenError
+	if !errors.As(err, &open) || open.Host != "example.com" {
+		t.Fatalf("Expected CircuitOpenError, got %v", err)
+	}
+	if _, err := breakers.allow("example.org"); err != nil {
+		t.Errorf("Expected other hosts to be allowed, got %v", err)
+	}
+
+	states := breakers.States()
+	if len(states) != 1 || states[0].State != CircuitOpen || states[0].Failures != 2 || states[0].RetryAt == nil {
+		t.Errorf("Unexpected states %+v", states)
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	breakers := NewBreakers(BreakerConfig{FailureThreshold: 1, OpenTimeout: 10 * time.Millisecond, HalfOpenRequests: 1})
+	record, _ := breakers.allow("example.com")
+	record(failed)
+	time.Sleep(20 * time.Millisecond)
+
+	probe, err := breakers.allow("example.com")
+	if err != nil {
+		t.Fatalf("Expected a probe after the open timeout, got %v", err)
+	}
+	if _, err := breakers.allow("example.com"); err == nil {
+		t.Fatal("Expected only one probe while half-open")
+	}
+	if states := breakers.States(); states[0].State != CircuitHalfOpen {
+		t.Errorf("Expected half-open state, got %+v", states)
+	}
+
+	probe(failed)
+	if _, err := breakers.allow("example.com"); err == nil {
+		t.Fatal("Expected the failed probe to open the circuit again")
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	probe, err = breakers.allow("example.com")
+	if err != nil {
+		t.Fatalf("Expected a probe after the open timeout, got %v", err)
+	}
+	probe(succeeded)
+	if states := breakers.States(); len(states) != 0 {
+		t.Errorf("Expected the circuit to close, got %+v", states)
+	}
+}
+
+func TestSendTask_CircuitBreaker(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	breakers := NewBreakers(BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute, HalfOpenRequests: 1})
+	client := NewClientWithBreakers(nil, breakers)
+	task := &model.Task{ID: 1, Method: "GET", URL: server.URL}
+
+	for i := 0; i < 3; i++ {
+		client.SendTask(context.Background(), task)
+	}
+	_, err := client.SendTask(context.Background(), task)
+	var open *CircuitOpenError
+	if !errors.As(err, &open) {
+		t.Errorf("Expected CircuitOpenError, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 requests to reach the server, got %d", requests)
+	}
+}
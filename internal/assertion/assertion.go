@@ -0,0 +1,138 @@
+package assertion
+
+import (
+	"MyFirstGoApp/internal/model"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Evaluate checks a response against the assertions of a task. It returns the
+// outcome of every assertion and whether all of them passed.
+func Evaluate(assertions *model.Assertions, resp *model.ResponseData) ([]model.AssertionResult, bool) {
+	var results []model.AssertionResult
+	if len(assertions.Status) > 0 {
+		results = append(results, checkStatus(assertions.Status, resp.StatusCode))
+	}
+
+	names := make([]string, 0, len(assertions.Headers))
+	for name := range assertions.Headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		results = append(results, checkHeader(resp, name, assertions.Headers[name]))
+	}
+
+	if assertions.BodyRegex != "" {
+		results = append(results, checkBodyRegex(assertions.BodyRegex, resp.Body))
+	}
+
+	if len(assertions.JSONPath) > 0 {
+		var body any
+		bodyErr := json.Unmarshal([]byte(resp.Body), &body)
+		for _, check := range assertions.JSONPath {
+			result := model.AssertionResult{Assertion: "json_path " + check.Path}
+			if bodyErr != nil {
+				result.Message = "response body is not JSON: " + bodyErr.Error()
+			} else {
+				result.Passed, result.Message = checkJSONPath(body, check)
+			}
+			results = append(results, result)
+		}
+	}
+
+	passed := true
+	for _, result := range results {
+		passed = passed && result.Passed
+	}
+	return results, passed
+}
+
+func checkStatus(patterns []string, code int) model.AssertionResult {
+	result := model.AssertionResult{Assertion: "status"}
+	for _, pattern := range patterns {
+		from, to, err := model.ParseStatusRange(pattern)
+		if err == nil && code >= from && code <= to {
+			result.Passed = true
+			return result
+		}
+	}
+	result.Message = fmt.Sprintf("status %d is not one of %s", code, strings.Join(patterns, ", "))
+	return result
+}
+
+func checkHeader(resp *model.ResponseData, name string, expected string) model.AssertionResult {
+	result := model.AssertionResult{Assertion: "header " + name}
+	values := resp.Headers.Values(name)
+	switch {
+	case len(values) == 0:
+		result.Message = "header is missing"
+	case expected == "" || slices.Contains(values, expected):
+		result.Passed = true
+	default:
+		result.Message = fmt.Sprintf("header is %q, expected %q", strings.Join(values, ", "), expected)
+	}
+	return result
+}
+
+func checkBodyRegex(pattern string, body string) model.AssertionResult {
+	result := model.AssertionResult{Assertion: "body_regex"}
+	re, err := regexp.Compile(pattern)
+	switch {
+	case err != nil:
+		result.Message = err.Error()
+	case re.MatchString(body):
+		result.Passed = true
+	default:
+		result.Message = "body does not match " + pattern
+	}
+	return result
+}
+
+func checkJSONPath(body any, check model.JSONPathAssertion) (bool, string) {
+	path, err := model.ParseJSONPath(check.Path)
+	if err != nil {
+		return false, err.Error()
+	}
+	var expected any
+	if err := json.Unmarshal(check.Equals, &expected); err != nil {
+		return false, "invalid expected value: " + err.Error()
+	}
+
+	actual, found := lookup(body, path)
+	if !found {
+		return false, "path not found"
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		encoded, _ := json.Marshal(actual)
+		return false, fmt.Sprintf("value is %s, expected %s", encoded, check.Equals)
+	}
+	return true, ""
+}
+
+func lookup(value any, path []any) (any, bool) {
+	for _, step := range path {
+		switch step := step.(type) {
+		case string:
+			object, ok := value.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			value, ok = object[step]
+			if !ok {
+				return nil, false
+			}
+		case int:
+			array, ok := value.([]any)
+			if !ok || step >= len(array) {
+				return nil, false
+			}
+			value = array[step]
+		}
+	}
+	return value, true
+}
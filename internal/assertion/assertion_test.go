@@ -0,0 +1,91 @@
+package assertion
+
+import (
+	"MyFirstGoApp/internal/model"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	resp := &model.ResponseData{
+		StatusCode: http.StatusCreated,
+		Headers:    http.Header{"Content-Type": {"application/json"}},
+		Body:       `{"ok":true,"data":{"items":[{"id":7}],"name":"a b"}}`,
+	}
+	assertions := &model.Assertions{
+		Status:    []string{"200", "201-204"},
+		Headers:   map[string]string{"content-type": "application/json", "X-Request-Id": ""},
+		BodyRegex: `"ok":\s*true`,
+		JSONPath: []model.JSONPathAssertion{
+			{Path: "$.data.items[0].id", Equals: json.RawMessage(`7`)},
+			{Path: "$.data['name']", Equals: json.RawMessage(`"a b"`)},
+			{Path: "$.data.missing", Equals: json.RawMessage(`null`)},
+		},
+	}
+
+	results, passed := Evaluate(assertions, resp)
+	if passed {
+		t.Error("Expected the assertions to fail")
+	}
+	var failed []string
+	for _, result := range results {
+		if !result.Passed {
+			failed = append(failed, result.Assertion)
+		}
+	}
+	expected := []string{"header X-Request-Id", "json_path $.data.missing"}
+	if !reflect.DeepEqual(failed, expected) {
+		t.Errorf("Expected failed assertions %v, got %v", expected, failed)
+	}
+	if len(results) != 7 {
+		t.Errorf("Expected 7 results, got %d", len(results))
+	}
+}
+
+func TestEvaluateStatus(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		code     int
+		passed   bool
+	}{
+		{[]string{"2xx"}, 204, true},
+		{[]string{"2xx"}, 500, false},
+		{[]string{"200", "404"}, 404, true},
+		{[]string{"500-503"}, 504, false},
+	}
+	for _, tt := range tests {
+		_, passed := Evaluate(&model.Assertions{Status: tt.patterns}, &model.ResponseData{StatusCode: tt.code})
+		if passed != tt.passed {
+			t.Errorf("Status %v with code %d: expected %v, got %v", tt.patterns, tt.code, tt.passed, passed)
+		}
+	}
+}
+
+func TestEvaluateNonJSONBody(t *testing.T) {
+	assertions := &model.Assertions{
+		JSONPath: []model.JSONPathAssertion{{Path: "$.id", Equals: json.RawMessage(`1`)}},
+	}
+	results, passed := Evaluate(assertions, &model.ResponseData{Body: "<html>"})
+	if passed || results[0].Message == "" {
+		t.Errorf("Expected a failed assertion with a message, got %+v", results)
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	steps, err := model.ParseJSONPath(`$.a[2]['b.c']["d"]`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []any{"a", 2, "b.c", "d"}
+	if !reflect.DeepEqual(steps, expected) {
+		t.Errorf("Expected %v, got %v", expected, steps)
+	}
+
+	for _, path := range []string{"a.b", "$.", "$[x]", "$[1", "$a"} {
+		if _, err := model.ParseJSONPath(path); err == nil {
+			t.Errorf("Expected error for %q, got nil", path)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"MyFirstGoApp/internal/model"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// keyPrefix starts every API key, so that leaked keys are easy to find.
+const keyPrefix = "tmk_"
+
+// prefixLength is the number of leading characters of a key kept in clear
+// text to recognize it.
+const prefixLength = len(keyPrefix) + 8
+
+var ErrInvalidKey = errors.New("invalid API key")
+
+// principalKey is the gin context key of the authenticated principal.
+const principalKey = "auth.principal"
+
+// Principal is the caller of an authenticated request.
+type Principal struct {
+	// Subject names the caller, such as "apikey:12" or "jwt:<sub>".
+	Subject string
+	// Tenant owns the tasks the caller creates and is the only tenant whose
+	// tasks it sees.
+	Tenant string
+	Scopes []string
+}
+
+// HasScope reports whether the principal may act with the scope. The tasks
+// admin scope grants the other scopes of the tenant's tasks, and the
+// deployment admin scope grants every scope.
+func (p Principal) HasScope(scope string) bool {
+	if slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, model.ScopeDeploymentAdmin) {
+		return true
+	}
+	return scope != model.ScopeDeploymentAdmin && slices.Contains(p.Scopes, model.ScopeTasksAdmin)
+}
+
+// GenerateKey returns a new random API key.
+func GenerateKey() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// HashKey returns the hash an API key is stored and looked up by. The keys are
+// random, so a fast hash is enough.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// KeyPrefix returns the leading characters of a key shown in key listings.
+func KeyPrefix(key string) string {
+	if len(key) <= prefixLength {
+		return key
+	}
+	return key[:prefixLength]
+}
+
+// Authenticator resolves the API key of a request.
+type Authenticator interface {
+	// AuthenticateAPIKey returns the active key, or ErrInvalidKey for an
+	// unknown or revoked one.
+	AuthenticateAPIKey(key string) (model.APIKey, error)
+}
+
+// TokenVerifier resolves the JWT bearer token of a request.
+type TokenVerifier interface {
+	// VerifyToken returns the principal of a valid token, or an error
+	// wrapping ErrInvalidToken.
+	VerifyToken(token string) (Principal, error)
+}
+
+// Middleware rejects requests without a valid API key or token with 401
+// Unauthorized. The API key is sent in the X-API-Key header or as a bearer
+// token; a bearer JWT is checked by verifier, which may be nil to accept API
+// keys only.
+func Middleware(authenticator Authenticator, verifier TokenVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		credential, bearer := requestCredential(c.Request)
+		if credential == "" {
+			unauthorized(c, "API key is required")
+			return
+		}
+		if bearer && verifier != nil && isJWT(credential) {
+			principal, err := verifier.VerifyToken(credential)
+			if err != nil {
+				unauthorized(c, err.Error())
+				return
+			}
+			// The prefix keeps token subjects apart from API key subjects.
+			principal.Subject = "jwt:" + principal.Subject
+			c.Set(principalKey, principal)
+			c.Next()
+			return
+		}
+
+		apiKey, err := authenticator.AuthenticateAPIKey(credential)
+		if errors.Is(err, ErrInvalidKey) {
+			unauthorized(c, err.Error())
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(principalKey, Principal{
+			Subject: "apikey:" + strconv.FormatInt(apiKey.ID, 10),
+			Tenant:  apiKey.Tenant,
+			Scopes:  apiKey.Scopes,
+		})
+		c.Next()
+	}
+}
+
+// RequireScope rejects requests whose principal lacks the scope with 403
+// Forbidden. It runs after Middleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := PrincipalFrom(c)
+		if !ok {
+			unauthorized(c, "API key is required")
+			return
+		}
+		if !principal.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key lacks the " + scope + " scope"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// PrincipalFrom returns the principal authenticated by Middleware.
+func PrincipalFrom(c *gin.Context) (Principal, bool) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return Principal{}, false
+	}
+	principal, ok := value.(Principal)
+	return principal, ok
+}
+
+// SubjectFrom returns the subject of the principal authenticated by
+// Middleware, or "" when the request is not authenticated.
+func SubjectFrom(c *gin.Context) string {
+	principal, _ := PrincipalFrom(c)
+	return principal.Subject
+}
+
+// TenantFrom returns the tenant of the principal authenticated by Middleware,
+// or "" when the request is not authenticated.
+func TenantFrom(c *gin.Context) string {
+	principal, _ := PrincipalFrom(c)
+	return principal.Tenant
+}
+
+// requestCredential returns the API key or token of the request, and whether
+// it was sent as a bearer token.
+func requestCredential(r *http.Request) (string, bool) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key, false
+	}
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token), true
+	}
+	return "", false
+}
+
+// isJWT tells a compact JWT from an API key, which has no dots.
+func isJWT(credential string) bool {
+	return strings.Count(credential, ".") == 2
+}
+
+func unauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="api"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": message})
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"MyFirstGoApp/internal/model"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeAuthenticator map[string]model.APIKey
+
+func (f fakeAuthenticator) AuthenticateAPIKey(key string) (model.APIKey, error) {
+	if key == "broken" {
+		return model.APIKey{}, errors.New("database is down")
+	}
+	apiKey, ok := f[key]
+	if !ok {
+		return model.APIKey{}, ErrInvalidKey
+	}
+	return apiKey, nil
+}
+
+func TestGenerateKey(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	other, _ := GenerateKey()
+	if key == other {
+		t.Error("Expected different keys")
+	}
+	if !strings.HasPrefix(key, keyPrefix) || len(key) < 40 {
+		t.Errorf("Unexpected key %q", key)
+	}
+	if prefix := KeyPrefix(key); !strings.HasPrefix(key, prefix) || len(prefix) != prefixLength {
+		t.Errorf("Unexpected prefix %q of key %q", prefix, key)
+	}
+	if HashKey(key) != HashKey(key) || HashKey(key) == HashKey(other) || strings.Contains(HashKey(key), key) {
+		t.Error("Expected a stable hash that differs between keys")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authenticator := fakeAuthenticator{
+		"reader":   {ID: 1, Scopes: []string{model.ScopeTasksRead}},
+		"admin":    {ID: 2, Scopes: []string{model.ScopeTasksAdmin}},
+		"operator": {ID: 3, Scopes: []string{model.ScopeDeploymentAdmin}},
+	}
+	router := gin.New()
+	api := router.Group("/", Middleware(authenticator, nil))
+	api.GET("/read", RequireScope(model.ScopeTasksRead), func(c *gin.Context) {
+		principal, _ := PrincipalFrom(c)
+		c.String(http.StatusOK, principal.Subject)
+	})
+	api.DELETE("/admin", RequireScope(model.ScopeTasksAdmin), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	api.GET("/deployment", RequireScope(model.ScopeDeploymentAdmin), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		value  string
+		status int
+	}{
+		{"No key", "GET", "/read", "", "", http.StatusUnauthorized},
+		{"Unknown key", "GET", "/read", "X-API-Key", "unknown", http.StatusUnauthorized},
+		{"Storage error", "GET", "/read", "X-API-Key", "broken", http.StatusInternalServerError},
+		{"Header key", "GET", "/read", "X-API-Key", "reader", http.StatusOK},
+		{"Bearer key", "GET", "/read", "Authorization", "Bearer reader", http.StatusOK},
+		{"Other scheme", "GET", "/read", "Authorization", "Basic reader", http.StatusUnauthorized},
+		{"Missing scope", "DELETE", "/admin", "X-API-Key", "reader", http.StatusForbidden},
+		{"Admin scope", "DELETE", "/admin", "X-API-Key", "admin", http.StatusNoContent},
+		{"Admin reads", "GET", "/read", "X-API-Key", "admin", http.StatusOK},
+		{"Admin of tenant", "GET", "/deployment", "X-API-Key", "admin", http.StatusForbidden},
+		{"Deployment admin", "GET", "/deployment", "X-API-Key", "operator", http.StatusOK},
+		{"Deployment admin deletes", "DELETE", "/admin", "X-API-Key", "operator", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("Expected status code %d, got %d", tt.status, w.Code)
+			}
+			if tt.status == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("Expected a WWW-Authenticate header")
+			}
+		})
+	}
+
+	req := httptest.NewRequest("GET", "/read", nil)
+	req.Header.Set("X-API-Key", "reader")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Body.String() != "apikey:1" {
+		t.Errorf("Expected subject apikey:1, got %q", w.Body.String())
+	}
+}
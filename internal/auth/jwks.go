@@ -0,0 +1,257 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minReloadInterval bounds how often the key set is reloaded, so that tokens
+// with made-up key IDs or an unavailable source cannot flood the JWKS source.
+const minReloadInterval = 10 * time.Second
+
+// maxJWKSSize bounds the JWKS document read from a file or URL.
+const maxJWKSSize = 1 << 20
+
+// JWKS is the set of public keys tokens are verified with, loaded from a
+// JWKS document in a file or at a URL. The set is reloaded every refresh
+// interval and when a token names an unknown key, so rotated keys are picked
+// up without a restart.
+type JWKS struct {
+	source  string
+	refresh time.Duration
+	client  *http.Client
+
+	mu       sync.Mutex
+	keys     map[string]any
+	loadedAt time.Time
+	triedAt  time.Time
+	// reloading is closed when the reload in progress finishes, and nil
+	// while there is none.
+	reloading chan struct{}
+}
+
+// NewJWKS loads the key set from source, a file path or an http(s) URL.
+func NewJWKS(source string, refresh time.Duration) (*JWKS, error) {
+	s := &JWKS{
+		source:  source,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+	keys, err := s.fetch()
+	if err != nil {
+		return nil, err
+	}
+	s.keys = keys
+	s.loadedAt = time.Now()
+	s.triedAt = s.loadedAt
+	return s, nil
+}
+
+// Key returns the public key with the key ID. A token without a key ID is
+// accepted only while the set has a single key.
+//
+// A known key is returned right away, a stale set being reloaded in the
+// background; only a token naming an unknown key waits for the reload.
+func (s *JWKS) Key(kid string) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	_, known := s.lookup(kid)
+	stale := now.Sub(s.loadedAt) >= s.refresh
+	if (stale || !known) && now.Sub(s.triedAt) >= minReloadInterval {
+		s.startReload(now)
+	}
+	if reloading := s.reloading; !known && reloading != nil {
+		s.mu.Unlock()
+		<-reloading
+		s.mu.Lock()
+	}
+
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key ID %q", kid)
+}
+
+// lookup returns the key with the key ID. The caller holds s.mu.
+func (s *JWKS) lookup(kid string) (any, bool) {
+	if key, ok := s.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// startReload reloads the keys in the background unless a reload is in
+// progress already. The caller holds s.mu.
+func (s *JWKS) startReload(now time.Time) {
+	if s.reloading != nil {
+		return
+	}
+	s.triedAt = now
+	s.reloading = make(chan struct{})
+	go s.reload(s.reloading)
+}
+
+// reload replaces the keys with the ones read from the source. The keys
+// loaded last stay in use while the source is unavailable.
+func (s *JWKS) reload(done chan struct{}) {
+	defer close(done)
+	keys, err := s.fetch()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reloading = nil
+	if err != nil {
+		log.Printf("Reloading JWKS from %s: %v", s.source, err)
+		return
+	}
+	s.keys = keys
+	s.loadedAt = time.Now()
+}
+
+func (s *JWKS) fetch() (map[string]any, error) {
+	data, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return parseJWKS(data)
+}
+
+func (s *JWKS) read() ([]byte, error) {
+	if !strings.HasPrefix(s.source, "http://") && !strings.HasPrefix(s.source, "https://") {
+		return os.ReadFile(s.source)
+	}
+
+	resp, err := s.client.Get(s.source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS reads the RSA, EC and Ed25519 signing keys of a JWKS document,
+// skipping the keys of other types.
+func parseJWKS(data []byte) (map[string]any, error) {
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("parsing JWKS: %w", err)
+	}
+
+	keys := make(map[string]any)
+	for i, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if errors.Is(err, errUnsupportedKey) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %d of the JWKS: %w", i, err)
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no signing keys")
+	}
+	return keys, nil
+}
+
+var errUnsupportedKey = errors.New("unsupported key type")
+
+func (jwk jsonWebKey) publicKey() (any, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errUnsupportedKey
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, errUnsupportedKey
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, errUnsupportedKey
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("invalid key parameter %q", value)
+	}
+	return new(big.Int).SetBytes(data), nil
+}
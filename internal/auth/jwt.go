@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"MyFirstGoApp/internal/model"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// tokenLeeway tolerates clock skew between the token issuer and the service.
+const tokenLeeway = 30 * time.Second
+
+// JWTConfig describes the tokens a JWTVerifier accepts.
+type JWTConfig struct {
+	// Issuer and Audience, when set, must match the iss and aud claims.
+	Issuer   string
+	Audience string
+	// SubjectClaim names the claim with the subject, "sub" by default.
+	SubjectClaim string
+	// ScopeClaim names the claim with the scopes, "scope" by default. The
+	// claim is a space-separated string or an array of strings.
+	ScopeClaim string
+	// TenantClaim names the claim with the tenant, "tenant" by default.
+	TenantClaim string
+}
+
+// JWTVerifier authenticates signed JWT bearer tokens with the keys of a JWKS.
+type JWTVerifier struct {
+	keys   *JWKS
+	parser *jwt.Parser
+	config JWTConfig
+}
+
+func NewJWTVerifier(keys *JWKS, config JWTConfig) *JWTVerifier {
+	if config.SubjectClaim == "" {
+		config.SubjectClaim = "sub"
+	}
+	if config.ScopeClaim == "" {
+		config.ScopeClaim = "scope"
+	}
+	if config.TenantClaim == "" {
+		config.TenantClaim = "tenant"
+	}
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(tokenLeeway),
+	}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+	return &JWTVerifier{keys: keys, parser: jwt.NewParser(options...), config: config}
+}
+
+// VerifyToken checks the signature and the claims of the token and returns
+// its principal. Scopes the service does not know are dropped.
+func (v *JWTVerifier) VerifyToken(token string) (Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.Key(kid)
+	})
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	subject, _ := claims[v.config.SubjectClaim].(string)
+	if subject == "" {
+		return Principal{}, fmt.Errorf("%w: no %s claim", ErrInvalidToken, v.config.SubjectClaim)
+	}
+	tenant, _ := claims[v.config.TenantClaim].(string)
+	if err := model.ValidateTenant(tenant); err != nil {
+		return Principal{}, fmt.Errorf("%w: %s claim: %v", ErrInvalidToken, v.config.TenantClaim, err)
+	}
+	return Principal{Subject: subject, Tenant: tenant, Scopes: tokenScopes(claims[v.config.ScopeClaim])}, nil
+}
+
+func tokenScopes(claim any) []string {
+	var values []string
+	switch claim := claim.(type) {
+	case string:
+		values = strings.Fields(claim)
+	case []any:
+		for _, value := range claim {
+			if value, ok := value.(string); ok {
+				values = append(values, value)
+			}
+		}
+	}
+
+	var scopes []string
+	for _, value := range values {
+		if slices.Contains(model.Scopes, value) && !slices.Contains(scopes, value) {
+			scopes = append(scopes, value)
+		}
+	}
+	return scopes
+}
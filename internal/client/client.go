@@ -2,8 +2,9 @@ package client
 
 import (
 	"MyFirstGoApp/internal/model"
+	"context"
 )
 
 type Client interface {
-	SendTask(task *model.Task) (*model.ResponseData, error)
+	SendTask(ctx context.Context, task *model.Task) (*model.ResponseData, error)
 }
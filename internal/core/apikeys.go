@@ -0,0 +1,102 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/auth"
+	"MyFirstGoApp/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// minAdminKeyLength keeps the bootstrap admin key from being guessable.
+const minAdminKeyLength = 32
+
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// IssueAPIKey creates an API key for the tasks of the tenant. Only its hash
+// is stored, so the returned key is the only copy of the secret.
+func (a *App) IssueAPIKey(name string, tenant string, scopes []string) (model.IssuedAPIKey, error) {
+	key := model.APIKey{Name: name, Tenant: tenant, Scopes: scopes}
+	if err := key.Validate(); err != nil {
+		return model.IssuedAPIKey{}, fmt.Errorf("%w: %v", ErrInvalidAPIKey, err)
+	}
+	secret, err := auth.GenerateKey()
+	if err != nil {
+		return model.IssuedAPIKey{}, fmt.Errorf("generating the API key: %w", err)
+	}
+	key.Prefix = auth.KeyPrefix(secret)
+	key.Hash = auth.HashKey(secret)
+	key.CreatedAt = time.Now()
+
+	key.ID, err = a.storage.AddAPIKey(key)
+	if err != nil {
+		return model.IssuedAPIKey{}, err
+	}
+	log.Printf("API key %d (%s) issued for tenant %s with scopes %v\n", key.ID, key.Name, key.Tenant, key.Scopes)
+	return model.IssuedAPIKey{APIKey: key, Key: secret}, nil
+}
+
+func (a *App) GetAPIKeys(tenant string) ([]model.APIKey, error) {
+	return a.storage.GetAllAPIKeys(tenant)
+}
+
+// RevokeAPIKey disables an API key of the tenant for good. It returns
+// sql.ErrNoRows when the tenant has no active key with the ID.
+func (a *App) RevokeAPIKey(tenant string, id int64) error {
+	err := a.storage.RevokeAPIKey(tenant, id, time.Now())
+	if err == nil {
+		log.Printf("API key %d of tenant %s revoked\n", id, tenant)
+	}
+	return err
+}
+
+// AuthenticateAPIKey returns the active API key with the secret, or
+// auth.ErrInvalidKey.
+func (a *App) AuthenticateAPIKey(secret string) (model.APIKey, error) {
+	key, err := a.storage.GetAPIKeyByHash(auth.HashKey(secret))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.APIKey{}, auth.ErrInvalidKey
+	}
+	if err != nil {
+		return model.APIKey{}, err
+	}
+	if key.RevokedAt != nil {
+		return model.APIKey{}, auth.ErrInvalidKey
+	}
+	return key, nil
+}
+
+// EnsureAdminKey stores the secret as a deployment admin API key of the
+// default tenant unless it is already known, so that the first keys can be
+// issued on a fresh database. A revoked bootstrap key stays revoked.
+func (a *App) EnsureAdminKey(secret string) error {
+	if len(secret) < minAdminKeyLength {
+		return fmt.Errorf("admin API key must be at least %d characters long", minAdminKeyLength)
+	}
+	hash := auth.HashKey(secret)
+	existing, err := a.storage.GetAPIKeyByHash(hash)
+	if err == nil {
+		if existing.RevokedAt != nil {
+			log.Printf("Bootstrap admin API key %d is revoked\n", existing.ID)
+		}
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	id, err := a.storage.AddAPIKey(model.APIKey{
+		Name:   "bootstrap admin",
+		Prefix: auth.KeyPrefix(secret),
+		Hash:   hash,
+		Scopes: []string{model.ScopeDeploymentAdmin},
+		Tenant: model.DefaultTenant,
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Bootstrap admin API key stored with ID %d\n", id)
+	return nil
+}
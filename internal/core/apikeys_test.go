@@ -0,0 +1,96 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/auth"
+	"MyFirstGoApp/internal/model"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIssueAPIKey(t *testing.T) {
+	mockStorage := &MockStorage{}
+	app := NewApp(mockStorage, WithQueue(&MockTaskQueue{}))
+	var stored model.APIKey
+	mockStorage.addKeyFunc = func(key model.APIKey) (int64, error) {
+		stored = key
+		return 7, nil
+	}
+
+	issued, err := app.IssueAPIKey("billing", "team-a", []string{model.ScopeTasksRead, model.ScopeTasksWrite})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if issued.ID != 7 || issued.Key == "" || !strings.HasPrefix(issued.Key, issued.Prefix) {
+		t.Errorf("Unexpected issued key %+v", issued)
+	}
+	if stored.Hash != auth.HashKey(issued.Key) || strings.Contains(stored.Hash, issued.Key) {
+		t.Error("Expected only the hash of the key to be stored")
+	}
+
+	for _, scopes := range [][]string{nil, {"tasks:delete"}} {
+		if _, err := app.IssueAPIKey("billing", "team-a", scopes); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("Expected ErrInvalidAPIKey for scopes %v, got %v", scopes, err)
+		}
+	}
+	if _, err := app.IssueAPIKey(" ", "team-a", []string{model.ScopeTasksRead}); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Expected ErrInvalidAPIKey for an empty name, got %v", err)
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	mockStorage := &MockStorage{}
+	app := NewApp(mockStorage, WithQueue(&MockTaskQueue{}))
+	revokedAt := time.Now()
+	keys := map[string]model.APIKey{
+		auth.HashKey("active"):  {ID: 1, Scopes: []string{model.ScopeTasksRead}},
+		auth.HashKey("revoked"): {ID: 2, RevokedAt: &revokedAt},
+	}
+	mockStorage.keyByHashFunc = func(hash string) (model.APIKey, error) {
+		if key, ok := keys[hash]; ok {
+			return key, nil
+		}
+		return (&MockStorage{}).GetAPIKeyByHash(hash)
+	}
+
+	if key, err := app.AuthenticateAPIKey("active"); err != nil || key.ID != 1 {
+		t.Errorf("Expected key 1, got %+v, %v", key, err)
+	}
+	for _, secret := range []string{"revoked", "unknown"} {
+		if _, err := app.AuthenticateAPIKey(secret); !errors.Is(err, auth.ErrInvalidKey) {
+			t.Errorf("Expected ErrInvalidKey for %s key, got %v", secret, err)
+		}
+	}
+}
+
+func TestEnsureAdminKey(t *testing.T) {
+	mockStorage := &MockStorage{}
+	app := NewApp(mockStorage, WithQueue(&MockTaskQueue{}))
+	var added []model.APIKey
+	mockStorage.addKeyFunc = func(key model.APIKey) (int64, error) {
+		added = append(added, key)
+		return int64(len(added)), nil
+	}
+	secret := strings.Repeat("s", minAdminKeyLength)
+
+	if err := app.EnsureAdminKey("short"); err == nil {
+		t.Error("Expected error for a short key, got nil")
+	}
+	if err := app.EnsureAdminKey(secret); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(added) != 1 || added[0].Hash != auth.HashKey(secret) || added[0].Scopes[0] != model.ScopeDeploymentAdmin {
+		t.Fatalf("Expected the admin key to be stored, got %+v", added)
+	}
+
+	mockStorage.keyByHashFunc = func(hash string) (model.APIKey, error) {
+		return added[0], nil
+	}
+	if err := app.EnsureAdminKey(secret); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(added) != 1 {
+		t.Errorf("Expected a known key not to be stored again, got %d keys", len(added))
+	}
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/model"
+	"log"
+	"time"
+)
+
+// recordAttempt saves the request and the outcome of one execution of a task
+// in its attempt history.
+func (a *App) recordAttempt(task model.Task, startedAt time.Time, resp *model.ResponseData, sendErr error) {
+	finishedAt := time.Now()
+	attempt := model.TaskAttempt{
+		TaskID:     task.ID,
+		Attempt:    task.Attempts,
+		StartedAt:  startedAt,
+		FinishedAt: finishedAt,
+		Duration:   model.Duration(finishedAt.Sub(startedAt)),
+		Request: model.AttemptRequest{
+			Method:  task.Method,
+			URL:     task.URL,
+			Headers: task.RequestHeaders(),
+			Body:    task.Body,
+		},
+		Response: resp,
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+
+	err := a.storage.AddTaskAttempt(attempt)
+	if err != nil {
+		log.Printf("Error saving attempt %d of task with ID %d: %v\n", attempt.Attempt, task.ID, err)
+	}
+}
+
+// GetTaskAttempts returns the attempt history of a task, oldest first.
+func (a *App) GetTaskAttempts(tenant string, id int64) ([]model.TaskAttempt, error) {
+	if _, err := a.storage.GetTaskByID(tenant, id); err != nil {
+		return nil, err
+	}
+	return a.storage.GetTaskAttempts(tenant, id)
+}
@@ -0,0 +1,19 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/HTTPclient"
+	"MyFirstGoApp/internal/model"
+)
+
+// WithBreakers replaces the circuit breakers of the destination hosts.
+func WithBreakers(breakers *HTTPclient.Breakers) Option {
+	return func(a *App) {
+		a.breakers = breakers
+	}
+}
+
+// GetBreakerStates returns the circuit breakers of the hosts that have failed
+// recently.
+func (a *App) GetBreakerStates() []model.BreakerState {
+	return a.breakers.States()
+}
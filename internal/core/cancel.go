@@ -0,0 +1,68 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/model"
+	"context"
+	"fmt"
+	"log"
+)
+
+// CancelTask stops a task. A task waiting in the queue, in the scheduler or
+// for a retry is marked cancelled and skipped by the workers; a task in flight
+// has its outbound request aborted.
+func (a *App) CancelTask(tenant string, id int64) (model.Task, error) {
+	for {
+		task, err := a.storage.GetTaskByID(tenant, id)
+		if err != nil {
+			return model.Task{}, err
+		}
+
+		switch task.Status {
+		case model.Done, model.Error, model.Cancelled:
+			return task, ErrTaskFinished
+		}
+
+		// The status is compared and swapped so that a worker claiming the
+		// task at the same moment is noticed; the loop then sees the new
+		// status.
+		cancelled, err := a.setStatusIf(&task, task.Status, model.Cancelled)
+		if err != nil {
+			return model.Task{}, fmt.Errorf("error updating the status of tasks to cancelled: %w", err)
+		}
+		if !cancelled {
+			continue
+		}
+
+		if a.scheduler != nil {
+			a.scheduler.Remove(id)
+		}
+		a.mu.Lock()
+		cancelRequest, ok := a.inFlight[id]
+		a.mu.Unlock()
+		if ok {
+			cancelRequest(nil)
+		} else {
+			// A task in flight is reported by its worker once the request
+			// is aborted.
+			a.notifyFinished(task)
+		}
+
+		log.Printf("Task with ID %d cancelled\n", id)
+		return task, nil
+	}
+}
+
+func (a *App) trackInFlight(id int64, cancel context.CancelCauseFunc) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.inFlight == nil {
+		a.inFlight = make(map[int64]context.CancelCauseFunc)
+	}
+	a.inFlight[id] = cancel
+}
+
+func (a *App) untrackInFlight(id int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.inFlight, id)
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/model"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCancelQueuedTask(t *testing.T) {
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	app := NewApp(mockStorage, WithQueue(mockQueue))
+
+	var mu sync.Mutex
+	stored := model.Task{ID: 1, Method: "GET", URL: "https://example.com", Status: model.New}
+	mockStorage.getByIDFunc = func(id int64) (model.Task, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return stored, nil
+	}
+	mockStorage.updateIfFunc = func(task *model.Task, current string, status string) (bool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if stored.Status != current {
+			return false, nil
+		}
+		stored.Status = status
+		task.Status = status
+		return true, nil
+	}
+	var statusUpdates []string
+	mockStorage.updateFunc = func(task *model.Task, status string) error {
+		statusUpdates = append(statusUpdates, status)
+		return nil
+	}
+
+	task, err := app.CancelTask("team-a", 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if task.Status != model.Cancelled {
+		t.Errorf("Expected status %s, got %s", model.Cancelled, task.Status)
+	}
+
+	app.Initworkers(1)
+	mockQueue.processFunc(model.Task{ID: 1, Method: "GET", URL: "https://example.com", Status: model.New})
+	if len(statusUpdates) != 0 {
+		t.Errorf("Expected cancelled task to be skipped, got status updates %v", statusUpdates)
+	}
+
+	_, err = app.CancelTask("team-a", 1)
+	if !errors.Is(err, ErrTaskFinished) {
+		t.Errorf("Expected ErrTaskFinished, got %v", err)
+	}
+}
+
+func TestCancelInFlightTask(t *testing.T) {
+	requestStarted := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requestStarted)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	app := NewApp(mockStorage, WithQueue(mockQueue))
+
+	var mu sync.Mutex
+	stored := model.Task{ID: 2, Method: "GET", URL: server.URL, Status: model.New}
+	mockStorage.getByIDFunc = func(id int64) (model.Task, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return stored, nil
+	}
+	mockStorage.updateFunc = func(task *model.Task, status string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		stored.Status = status
+		return nil
+	}
+	app.Initworkers(1)
+
+	task := stored
+	done := make(chan struct{})
+	go func() {
+		mockQueue.processFunc(task)
+		close(done)
+	}()
+
+	select {
+	case <-requestStarted:
+	case <-time.After(time.Second):
+		t.Fatal("Request was not sent")
+	}
+	if _, err := app.CancelTask("team-a", 2); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("In-flight request was not aborted")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if stored.Status != model.Cancelled {
+		t.Errorf("Expected status %s, got %s", model.Cancelled, stored.Status)
+	}
+}
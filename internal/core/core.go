@@ -2,57 +2,359 @@ package core
 
 import (
 	"MyFirstGoApp/internal/HTTPclient"
+	"MyFirstGoApp/internal/assertion"
+	"MyFirstGoApp/internal/client"
+	"MyFirstGoApp/internal/events"
 	"MyFirstGoApp/internal/model"
 	"MyFirstGoApp/internal/queue"
+	"MyFirstGoApp/internal/quota"
+	"MyFirstGoApp/internal/ratelimit"
+	"MyFirstGoApp/internal/retry"
+	"MyFirstGoApp/internal/scheduler"
 	"MyFirstGoApp/internal/storage"
+	"MyFirstGoApp/internal/webhook"
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidSchedule = errors.New("invalid schedule")
+	ErrTaskFinished    = errors.New("task has already finished")
 )
 
 type App struct {
-	storage storage.Storage
-	q       queue.TaskQueue
+	storage   storage.Storage
+	q         queue.TaskQueue
+	scheduler *scheduler.Scheduler
+
+	newClient func(options *model.RequestOptions) client.Client
+	webhooks  Notifier
+	limiter   *ratelimit.Limiter
+	quotas    *quota.Quotas
+	breakers  *HTTPclient.Breakers
+	events    *events.Broker
+
+	instanceID   string
+	leaseTimeout time.Duration
+
+	mu       sync.Mutex
+	inFlight map[int64]context.CancelCauseFunc
+	waiting  map[int64][]chan struct{}
+
+	closeMu    sync.RWMutex
+	closing    bool
+	stopped    chan struct{}
+	workers    sync.WaitGroup
+	deliveries sync.WaitGroup
 }
 
-func NewApp(store storage.Storage) *App {
-	q := queue.NewTasksQueue(100)
-	return &App{
-		storage: store,
-		q:       q,
+type Option func(*App)
+
+// WithQueue replaces the default in-memory queue.
+func WithQueue(q queue.TaskQueue) Option {
+	return func(a *App) {
+		a.q = q
 	}
 }
+
+// WithClientFactory replaces the function that builds the HTTP client for the
+// request options of a task.
+func WithClientFactory(newClient func(options *model.RequestOptions) client.Client) Option {
+	return func(a *App) {
+		a.newClient = newClient
+	}
+}
+
+func NewApp(store storage.Storage, opts ...Option) *App {
+	app := &App{
+		storage:    store,
+		stopped:    make(chan struct{}),
+		instanceID: newInstanceID(),
+	}
+	for _, opt := range opts {
+		opt(app)
+	}
+	if app.q == nil {
+		app.q = queue.NewTasksQueue(100)
+	}
+	if app.breakers == nil {
+		app.breakers = HTTPclient.NewBreakers(HTTPclient.DefaultBreakerConfig)
+	}
+	if app.limiter == nil {
+		app.limiter = ratelimit.New(nil)
+	}
+	if app.quotas == nil {
+		app.quotas = quota.New(nil)
+	}
+	if app.events == nil {
+		app.events = events.NewBroker(events.DefaultHistorySize)
+	}
+	if app.webhooks == nil {
+		app.webhooks = webhook.NewNotifier(webhook.DefaultPolicy, app.recordDelivery)
+	}
+	app.scheduler = scheduler.New(app.releaseScheduled)
+	return app
+}
 func (a *App) Initworkers(num int) {
-	a.q.Start(num, func(task model.Task) {
-		client := HTTPclient.NewClient()
-		err := a.storage.UpdateTaskStatus(&task, model.In_process)
+	if a.quotas != nil {
+		a.quotas.SetWorkers(num)
+		// Queues that can pass over tenants do not hand out the tasks of a
+		// tenant using its whole share of the workers.
+		if limited, ok := a.q.(queue.TenantLimited); ok {
+			limited.HoldBack(a.quotas.FullTenants)
+		}
+	}
+	a.q.Start(num, a.processTask)
+	a.recoverPending()
+	a.startScheduler()
+	go a.runSchedules()
+	go a.runHeartbeats()
+	go a.runReaper()
+}
+
+// startScheduler restores the scheduled tasks saved before a restart and
+// starts releasing them into the queue.
+func (a *App) startScheduler() {
+	if a.scheduler == nil {
+		return
+	}
+	tasks, err := a.storage.GetTasksByStatus(model.Scheduled)
+	if err != nil {
+		log.Printf("Error loading scheduled tasks: %v\n", err)
+	}
+	for _, task := range tasks {
+		a.scheduler.Schedule(task)
+	}
+	a.scheduler.Start()
+}
+
+func (a *App) releaseScheduled(task model.Task) {
+	released, err := a.setStatusIf(&task, model.Scheduled, model.New)
+	if err != nil {
+		log.Printf("Error updating the status of tasks to new: %v\n", err)
+		return
+	}
+	if !released {
+		// Another instance has already released or claimed the task.
+		return
+	}
+	a.enqueue(task)
+	log.Printf("Scheduled task with ID %d added to processing queue\n", task.ID)
+}
+
+func (a *App) processTask(task model.Task) {
+	if !a.beginWork() {
+		// The queue can hand out a task after Shutdown has started.
+		if task.Status == model.In_process {
+			a.releaseTask(task)
+		}
+		return
+	}
+	defer a.workers.Done()
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	a.trackInFlight(task.ID, cancel)
+	defer a.untrackInFlight(task.ID)
+
+	// A task cancelled while it waited in the queue is skipped.
+	if current, err := a.storage.GetTaskByID(task.Tenant, task.ID); err == nil && current.Status == model.Cancelled {
+		log.Printf("Task with ID %d was cancelled before processing\n", task.ID)
+		return
+	}
+	releaseWorker, ok := a.acquireWorker(task.Tenant)
+	if !ok {
+		log.Printf("Task with ID %d deferred, tenant %s uses its share of the workers\n", task.ID, task.Tenant)
+		a.deferForTenant(task)
+		return
+	}
+	defer releaseWorker()
+	release, wait := a.acquireHost(task.URL)
+	if release == nil {
+		log.Printf("Task with ID %d deferred by %s: rate limit of its host\n", task.ID, wait)
+		a.postpone(task, wait)
+		return
+	}
+
+	client := a.clientFor(task.Options)
+	// The lease comes first, so the reaper never sees the task in process
+	// without one.
+	a.takeLease(&task)
+	err := a.setStatus(&task, model.In_process)
+	if err != nil {
+		log.Printf("Error updating the status of tasks to in_progress: %v\n", err)
+	}
+	task.Attempts++
+	task.NextRetryAt = nil
+	err = a.storage.UpdateTaskAttempts(&task)
+	if err != nil {
+		log.Printf("Error updating the attempts of task: %v\n", err)
+	}
+
+	var circuitOpen *HTTPclient.CircuitOpenError
+	startedAt := time.Now()
+	resp, err := client.SendTask(ctx, &task)
+	release()
+	// A request stopped by an open circuit was never sent.
+	if !errors.As(err, &circuitOpen) {
+		a.recordAttempt(task, startedAt, resp, err)
+	}
+	if context.Cause(ctx) == errShutdown {
+		a.releaseTask(task)
+		return
+	}
+	if ctx.Err() != nil {
+		log.Printf("Task with ID %d was cancelled while in flight\n", task.ID)
+		err := a.setStatus(&task, model.Cancelled)
 		if err != nil {
-			log.Printf("Error updating the status of tasks to in_progress: %v\n", err)
+			log.Printf("Error updating the status of tasks to cancelled: %v\n", err)
+		}
+		a.notifyFinished(task)
+		return
+	}
+	if circuitOpen != nil && a.breakers != nil && a.breakers.Config().OpenAction == HTTPclient.OpenDefer {
+		a.deferTask(task, circuitOpen)
+		return
+	}
+	retryable := retry.Retryable(task.Retry, resp, err)
+	if retryable && task.Attempts < task.Retry.MaxAttempts {
+		reason := err
+		if reason == nil {
+			reason = fmt.Errorf("response status %d", resp.StatusCode)
 		}
-		resp, err := client.SendTask(&task)
+		a.scheduleRetry(task, reason)
+		return
+	}
+
+	if err != nil {
+		log.Printf("Error sending task to third-party service: %v\n", err)
+		err := a.setStatus(&task, model.Error)
 		if err != nil {
-			log.Printf("Error sending task to third-party service: %v\n", err)
-			err := a.storage.UpdateTaskStatus(&task, model.Error)
-			if err != nil {
-				log.Printf("Error updating the status of tasks to error: %v\n", err)
-			}
-		} else {
-			log.Printf("Task with ID %d sent to third-party service successfully\n", task.ID)
-			err := a.storage.UpdateTaskStatus(&task, model.Done)
-			if err != nil {
-				log.Printf("Error updating the status of tasks to done: %v\n", err)
-			}
-			err = a.storage.UpdateTaskResponse(&task, resp)
-			if err != nil {
-				log.Printf("Error updating the response data: %v\n", err)
-			}
+			log.Printf("Error updating the status of tasks to error: %v\n", err)
 		}
+		a.notifyFinished(task)
+		return
+	}
+
+	status := model.Done
+	if task.Assertions != nil {
+		results, passed := assertion.Evaluate(task.Assertions, resp)
+		task.AssertionResults = results
+		err = a.storage.UpdateTaskAssertionResults(&task)
+		if err != nil {
+			log.Printf("Error updating the assertion results: %v\n", err)
+		}
+		if !passed {
+			log.Printf("Response of task with ID %d failed its assertions\n", task.ID)
+			status = model.Error
+		}
+	}
+	if retryable {
+		log.Printf("Task with ID %d got status %d after %d attempts\n", task.ID, resp.StatusCode, task.Attempts)
+		status = model.Error
+	} else if status == model.Done {
+		log.Printf("Task with ID %d sent to third-party service successfully\n", task.ID)
+	}
+	err = a.setStatus(&task, status)
+	if err != nil {
+		log.Printf("Error updating the status of tasks to %s: %v\n", status, err)
+	}
+	err = a.storage.UpdateTaskResponse(&task, resp)
+	if err != nil {
+		log.Printf("Error updating the response data: %v\n", err)
+	} else {
+		task.Response = *resp
+		a.publish(events.ResponseStored, task)
+	}
+	a.notifyFinished(task)
+}
+
+func (a *App) clientFor(options *model.RequestOptions) client.Client {
+	if a.newClient != nil {
+		return a.newClient(options)
+	}
+	return HTTPclient.NewClientWithBreakers(options, a.breakers)
+}
+
+// scheduleRetry puts the task back into the queue once the backoff delay of
+// its retry policy has passed.
+func (a *App) scheduleRetry(task model.Task, reason error) {
+	delay := retry.Delay(task.Retry, task.Attempts)
+	log.Printf("Attempt %d of task with ID %d failed (%v), retrying in %s\n", task.Attempts, task.ID, reason, delay)
+	a.requeue(task, delay)
+}
+
+// deferTask puts a task whose host has an open circuit back into the queue
+// for when the circuit lets requests through again. The attempt does not
+// count against the retry policy.
+func (a *App) deferTask(task model.Task, open *HTTPclient.CircuitOpenError) {
+	task.Attempts--
+	delay := time.Until(open.RetryAt)
+	log.Printf("Task with ID %d deferred by %s: %v\n", task.ID, delay, open)
+	a.requeue(task, delay)
+}
+
+// requeue marks the task as retrying and puts it back into the queue once
+// delay has passed.
+func (a *App) requeue(task model.Task, delay time.Duration) {
+	nextRetryAt := time.Now().Add(delay)
+	task.NextRetryAt = &nextRetryAt
+
+	err := a.setStatus(&task, model.Retrying)
+	if err != nil {
+		log.Printf("Error updating the status of tasks to retrying: %v\n", err)
+	}
+	err = a.storage.UpdateTaskAttempts(&task)
+	if err != nil {
+		log.Printf("Error updating the attempts of task: %v\n", err)
+	}
+
+	time.AfterFunc(delay, func() {
+		a.enqueue(task)
+	})
+}
+
+// postpone puts a task that was not attempted back into the queue once delay
+// has passed, without a new status or attempt. A task the PostgreSQL queue
+// has claimed is released first, unless it was cancelled in the meantime.
+func (a *App) postpone(task model.Task, delay time.Duration) {
+	if task.Status == model.In_process {
+		released, err := a.setStatusIf(&task, model.In_process, model.New)
+		if err != nil {
+			log.Printf("Error updating the status of tasks to new: %v\n", err)
+		}
+		if !released {
+			return
+		}
+	}
+	nextRetryAt := time.Now().Add(delay)
+	task.NextRetryAt = &nextRetryAt
+	err := a.storage.UpdateTaskAttempts(&task)
+	if err != nil {
+		log.Printf("Error updating the attempts of task: %v\n", err)
+	}
+
+	time.AfterFunc(delay, func() {
+		a.enqueue(task)
 	})
 }
 
 func (a *App) CreateTask(task model.Task) (int64, error) {
-	err := a.storage.UpdateTaskStatus(&task, model.New)
+	if a.shuttingDown() {
+		return 0, ErrShuttingDown
+	}
+	if err := a.checkQueueDepth(task.Tenant, 1); err != nil {
+		return 0, err
+	}
+	scheduled := prepareTask(&task)
+
+	err := a.storage.UpdateTaskStatus(&task, task.Status)
 	if err != nil {
-		return 0, fmt.Errorf("error updating the status of tasks to new: %w", err)
+		return 0, fmt.Errorf("error updating the status of tasks to %s: %w", task.Status, err)
 	}
 
 	id, err := a.storage.AddTask(task)
@@ -64,24 +366,88 @@ func (a *App) CreateTask(task model.Task) (int64, error) {
 	task.ID = id
 	log.Printf("Task created successfully with ID %d\n", id)
 
-	a.q.Enqueque(task)
-	log.Printf("Task with ID %d added to processing queue\n", id)
+	a.dispatch(task, scheduled)
 	return id, err
 
 }
 
-func (a *App) GetAllTasks() ([]model.Task, error) {
-	return a.storage.GetAllTasks()
+// CreateTasks stores a batch of tasks in one transaction and then queues or
+// schedules each of them. The returned IDs are in the order of the tasks.
+func (a *App) CreateTasks(tasks []model.Task) ([]int64, error) {
+	if a.shuttingDown() {
+		return nil, ErrShuttingDown
+	}
+	adding := make(map[string]int)
+	for _, task := range tasks {
+		adding[task.Tenant]++
+	}
+	for tenant, count := range adding {
+		if err := a.checkQueueDepth(tenant, count); err != nil {
+			return nil, err
+		}
+	}
+	scheduled := make([]bool, len(tasks))
+	for i := range tasks {
+		scheduled[i] = prepareTask(&tasks[i])
+	}
+
+	ids, err := a.storage.AddTasks(tasks)
+	if err != nil {
+		log.Printf("Error adding tasks to database: %v\n", err)
+		return nil, fmt.Errorf("adding tasks to database error: %w", err)
+	}
+	log.Printf("Batch of %d tasks created successfully\n", len(ids))
+
+	for i := range tasks {
+		tasks[i].ID = ids[i]
+	}
+	// The in-memory queue blocks while it is full, so a large batch is fed
+	// to it without holding up the request.
+	go func() {
+		for i, task := range tasks {
+			a.dispatch(task, scheduled[i])
+		}
+	}()
+	return ids, nil
+}
+
+// prepareTask sets the initial status of a new task and turns its delay into
+// a run time. It reports whether the task has to wait in the scheduler.
+func prepareTask(task *model.Task) bool {
+	if task.Delay > 0 {
+		runAt := time.Now().Add(time.Duration(task.Delay))
+		task.RunAt = &runAt
+	}
+	scheduled := task.RunAt != nil && task.RunAt.After(time.Now())
+
+	task.Status = model.New
+	if scheduled {
+		task.Status = model.Scheduled
+	}
+	task.StampStatus(task.Status, time.Now())
+	return scheduled
+}
+
+func (a *App) dispatch(task model.Task, scheduled bool) {
+	a.publish(events.StatusChanged, task)
+	if scheduled {
+		a.scheduler.Schedule(task)
+		log.Printf("Task with ID %d scheduled for %s\n", task.ID, task.RunAt.Format(time.RFC3339))
+		return
+	}
+
+	a.enqueue(task)
+	log.Printf("Task with ID %d added to processing queue\n", task.ID)
 }
 
-func (a *App) CleanStorage() error {
-	return a.storage.CleanStorage()
+func (a *App) CleanStorage(tenant string) error {
+	return a.storage.CleanStorage(tenant)
 }
 
-func (a *App) GetTaskByID(id int64) (model.Task, error) {
-	return a.storage.GetTaskByID(id)
+func (a *App) GetTaskByID(tenant string, id int64) (model.Task, error) {
+	return a.storage.GetTaskByID(tenant, id)
 }
 
-func (a *App) DeleteTaskByID(id int64) (int64, error) {
-	return a.storage.DeleteTaskByID(id)
+func (a *App) DeleteTaskByID(tenant string, id int64) (int64, error) {
+	return a.storage.DeleteTaskByID(tenant, id)
 }
@@ -1,20 +1,48 @@
 package core
 
 import (
+	"MyFirstGoApp/internal/HTTPclient"
+	"MyFirstGoApp/internal/client"
 	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/ratelimit"
+	"MyFirstGoApp/internal/storage"
+	"context"
+	"database/sql"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 )
 
 type MockStorage struct {
 	tasks          []model.Task
 	addTaskFunc    func(task model.Task) (int64, error)
+	addTasksFunc   func(tasks []model.Task) ([]int64, error)
 	updateFunc     func(task *model.Task, status string) error
 	updateRespFunc func(task *model.Task, resp *model.ResponseData) error
-	getAllFunc     func() ([]model.Task, error)
+	attemptsFunc   func(task *model.Task) error
+	assertionsFunc func(task *model.Task) error
+	addAttemptFunc func(attempt model.TaskAttempt) error
+	addKeyFunc     func(key model.APIKey) (int64, error)
+	keyByHashFunc  func(hash string) (model.APIKey, error)
+	queryFunc      func(query storage.TaskQuery) ([]model.Task, error)
+	leaseFunc      func(task *model.Task, owner string, expiresAt time.Time) error
+	expiredFunc    func(now time.Time) ([]model.Task, error)
+	reclaimFunc    func(task *model.Task, status string) (bool, error)
+	pendingFunc    func() ([]model.Task, error)
+	updateIfFunc   func(task *model.Task, current string, status string) (bool, error)
+	getByStatus    func(status string) ([]model.Task, error)
+	dueSchedules   func(now time.Time) ([]model.Schedule, error)
+	claimSchedule  func(id int64, prevRunAt time.Time, nextRunAt time.Time) (bool, error)
+	scheduleError  func(id int64, message string) error
 	getByIDFunc    func(id int64) (model.Task, error)
+	getByKeyFunc   func(key string) (model.Task, error)
 	deleteFunc     func(id int64) (int64, error)
 	cleanFunc      func() error
+	countFunc      func(tenant string) (int, error)
 }
 
 func (m *MockStorage) AddTask(task model.Task) (int64, error) {
@@ -24,6 +52,13 @@ func (m *MockStorage) AddTask(task model.Task) (int64, error) {
 	return 0, nil
 }
 
+func (m *MockStorage) AddTasks(tasks []model.Task) ([]int64, error) {
+	if m.addTasksFunc != nil {
+		return m.addTasksFunc(tasks)
+	}
+	return make([]int64, len(tasks)), nil
+}
+
 func (m *MockStorage) UpdateTaskStatus(task *model.Task, status string) error {
 	if m.updateFunc != nil {
 		return m.updateFunc(task, status)
@@ -31,6 +66,14 @@ func (m *MockStorage) UpdateTaskStatus(task *model.Task, status string) error {
 	return nil
 }
 
+func (m *MockStorage) UpdateTaskStatusIf(task *model.Task, current string, status string) (bool, error) {
+	if m.updateIfFunc != nil {
+		return m.updateIfFunc(task, current, status)
+	}
+	task.Status = status
+	return true, nil
+}
+
 func (m *MockStorage) UpdateTaskResponse(task *model.Task, resp *model.ResponseData) error {
 	if m.updateRespFunc != nil {
 		return m.updateRespFunc(task, resp)
@@ -38,40 +81,206 @@ func (m *MockStorage) UpdateTaskResponse(task *model.Task, resp *model.ResponseD
 	return nil
 }
 
-func (m *MockStorage) GetAllTasks() ([]model.Task, error) {
-	if m.getAllFunc != nil {
-		return m.getAllFunc()
+func (m *MockStorage) UpdateTaskAttempts(task *model.Task) error {
+	if m.attemptsFunc != nil {
+		return m.attemptsFunc(task)
 	}
-	return m.tasks, nil
+	return nil
 }
 
-func (m *MockStorage) GetTaskByID(id int64) (model.Task, error) {
+func (m *MockStorage) UpdateTaskAssertionResults(task *model.Task) error {
+	if m.assertionsFunc != nil {
+		return m.assertionsFunc(task)
+	}
+	return nil
+}
+
+func (m *MockStorage) GetTaskByID(tenant string, id int64) (model.Task, error) {
 	if m.getByIDFunc != nil {
 		return m.getByIDFunc(id)
 	}
 	for _, task := range m.tasks {
-		if task.ID == id {
+		if task.ID == id && task.Tenant == tenant {
 			return task, nil
 		}
 	}
 	return model.Task{}, errors.New("task not found")
 }
 
-func (m *MockStorage) DeleteTaskByID(id int64) (int64, error) {
+func (m *MockStorage) GetTaskByIdempotencyKey(tenant string, key string) (model.Task, error) {
+	if m.getByKeyFunc != nil {
+		return m.getByKeyFunc(key)
+	}
+	return model.Task{}, sql.ErrNoRows
+}
+
+func (m *MockStorage) GetTasksByStatus(status string) ([]model.Task, error) {
+	if m.getByStatus != nil {
+		return m.getByStatus(status)
+	}
+	return nil, nil
+}
+
+func (m *MockStorage) DeleteTaskByID(tenant string, id int64) (int64, error) {
 	if m.deleteFunc != nil {
 		return m.deleteFunc(id)
 	}
 	return 0, nil
 }
 
-func (m *MockStorage) CleanStorage() error {
+func (m *MockStorage) CleanStorage(tenant string) error {
 	if m.cleanFunc != nil {
 		return m.cleanFunc()
 	}
-	m.tasks = []model.Task{}
+	m.tasks = slices.DeleteFunc(m.tasks, func(task model.Task) bool {
+		return task.Tenant == tenant
+	})
 	return nil
 }
 
+func (m *MockStorage) CountUnfinishedTasks(tenant string) (int, error) {
+	if m.countFunc != nil {
+		return m.countFunc(tenant)
+	}
+	count := 0
+	for _, task := range m.tasks {
+		if task.Tenant == tenant && slices.Contains(model.UnfinishedStatuses, task.Status) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MockStorage) GetTasksBySchedule(tenant string, scheduleID int64) ([]model.Task, error) {
+	return nil, nil
+}
+
+func (m *MockStorage) AddSchedule(schedule model.Schedule) (int64, error) {
+	return 0, nil
+}
+
+func (m *MockStorage) GetAllSchedules(tenant string) ([]model.Schedule, error) {
+	return nil, nil
+}
+
+func (m *MockStorage) GetScheduleByID(tenant string, id int64) (model.Schedule, error) {
+	return model.Schedule{}, errors.New("schedule not found")
+}
+
+func (m *MockStorage) GetDueSchedules(now time.Time) ([]model.Schedule, error) {
+	if m.dueSchedules != nil {
+		return m.dueSchedules(now)
+	}
+	return nil, nil
+}
+
+func (m *MockStorage) UpdateSchedule(schedule model.Schedule) error {
+	return nil
+}
+
+func (m *MockStorage) ClaimScheduleRun(id int64, prevRunAt time.Time, nextRunAt time.Time) (bool, error) {
+	if m.claimSchedule != nil {
+		return m.claimSchedule(id, prevRunAt, nextRunAt)
+	}
+	return true, nil
+}
+
+func (m *MockStorage) SetScheduleError(id int64, message string) error {
+	if m.scheduleError != nil {
+		return m.scheduleError(id, message)
+	}
+	return nil
+}
+
+func (m *MockStorage) DeleteScheduleByID(tenant string, id int64) error {
+	return nil
+}
+
+func (m *MockStorage) QueryTasks(query storage.TaskQuery) ([]model.Task, error) {
+	if m.queryFunc != nil {
+		return m.queryFunc(query)
+	}
+	return nil, nil
+}
+
+func (m *MockStorage) UpdateTaskLease(task *model.Task, owner string, expiresAt time.Time) error {
+	if m.leaseFunc != nil {
+		return m.leaseFunc(task, owner, expiresAt)
+	}
+	return nil
+}
+
+func (m *MockStorage) ExtendTaskLeases(owner string, ids []int64, expiresAt time.Time) error {
+	return nil
+}
+
+func (m *MockStorage) GetExpiredTasks(now time.Time) ([]model.Task, error) {
+	if m.expiredFunc != nil {
+		return m.expiredFunc(now)
+	}
+	return nil, nil
+}
+
+func (m *MockStorage) ReclaimTask(task *model.Task, status string) (bool, error) {
+	if m.reclaimFunc != nil {
+		return m.reclaimFunc(task, status)
+	}
+	task.Status = status
+	return true, nil
+}
+
+func (m *MockStorage) GetPendingTasks() ([]model.Task, error) {
+	if m.pendingFunc != nil {
+		return m.pendingFunc()
+	}
+	return nil, nil
+}
+
+func (m *MockStorage) AddTaskAttempt(attempt model.TaskAttempt) error {
+	if m.addAttemptFunc != nil {
+		return m.addAttemptFunc(attempt)
+	}
+	return nil
+}
+
+func (m *MockStorage) GetTaskAttempts(tenant string, taskID int64) ([]model.TaskAttempt, error) {
+	return []model.TaskAttempt{}, nil
+}
+
+func (m *MockStorage) GetTaskTransitions(tenant string, taskID int64) ([]model.TaskTransition, error) {
+	return []model.TaskTransition{}, nil
+}
+
+func (m *MockStorage) AddAPIKey(key model.APIKey) (int64, error) {
+	if m.addKeyFunc != nil {
+		return m.addKeyFunc(key)
+	}
+	return 1, nil
+}
+
+func (m *MockStorage) GetAPIKeyByHash(hash string) (model.APIKey, error) {
+	if m.keyByHashFunc != nil {
+		return m.keyByHashFunc(hash)
+	}
+	return model.APIKey{}, sql.ErrNoRows
+}
+
+func (m *MockStorage) GetAllAPIKeys(tenant string) ([]model.APIKey, error) {
+	return []model.APIKey{}, nil
+}
+
+func (m *MockStorage) RevokeAPIKey(tenant string, id int64, revokedAt time.Time) error {
+	return nil
+}
+
+func (m *MockStorage) AddWebhookDelivery(delivery model.WebhookDelivery) error {
+	return nil
+}
+
+func (m *MockStorage) GetWebhookDeliveries(tenant string, taskID int64) ([]model.WebhookDelivery, error) {
+	return []model.WebhookDelivery{}, nil
+}
+
 type MockTaskQueue struct {
 	tasks       []model.Task
 	enqueueFunc func(task model.Task)
@@ -261,46 +470,62 @@ func TestCreateTask(t *testing.T) {
 	})
 }
 
-func TestGetAllTasks(t *testing.T) {
+func TestCreateTasks(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		mockStorage := &MockStorage{}
 		mockQueue := &MockTaskQueue{}
+		app := NewApp(mockStorage, WithQueue(mockQueue))
 
-		app := &App{
-			storage: mockStorage,
-			q:       mockQueue,
-		}
-		expectedTasks := []model.Task{
-			{ID: 1, Method: "GET", URL: "https://example.com/1"},
-			{ID: 2, Method: "POST", URL: "https://example.com/2"},
+		enqueued := make(chan model.Task, 3)
+		mockQueue.enqueueFunc = func(task model.Task) {
+			enqueued <- task
 		}
-		mockStorage.getAllFunc = func() ([]model.Task, error) {
-			return expectedTasks, nil
+		mockStorage.addTasksFunc = func(tasks []model.Task) ([]int64, error) {
+			if len(tasks) != 3 {
+				t.Errorf("Expected 3 tasks, got %d", len(tasks))
+			}
+			for _, task := range tasks {
+				if task.Status != model.New {
+					t.Errorf("Expected status %s, got %s", model.New, task.Status)
+				}
+			}
+			return []int64{10, 11, 12}, nil
 		}
-		tasks, err := app.GetAllTasks()
+
+		ids, err := app.CreateTasks([]model.Task{
+			{Method: "GET", URL: "https://example.com/1"},
+			{Method: "GET", URL: "https://example.com/2"},
+			{Method: "GET", URL: "https://example.com/3"},
+		})
 		if err != nil {
-			t.Errorf("Expected no error, got %v", err)
+			t.Fatalf("Expected no error, got %v", err)
 		}
-		if len(tasks) != len(expectedTasks) {
-			t.Errorf("Expected %d tasks, got %d", len(expectedTasks), len(tasks))
+		if len(ids) != 3 || ids[0] != 10 || ids[2] != 12 {
+			t.Errorf("Unexpected IDs %v", ids)
 		}
-		for i, task := range tasks {
-			if task.ID != expectedTasks[i].ID {
-				t.Errorf("Expected task ID %d, got %d", expectedTasks[i].ID, task.ID)
+		for _, id := range ids {
+			select {
+			case task := <-enqueued:
+				if task.ID != id {
+					t.Errorf("Expected task ID %d, got %d", id, task.ID)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("Task %d was not enqueued", id)
 			}
 		}
 	})
-	t.Run("Error", func(t *testing.T) {
+
+	t.Run("AddTasksError", func(t *testing.T) {
 		mockStorage := &MockStorage{}
 		mockQueue := &MockTaskQueue{}
-		app := &App{
-			storage: mockStorage,
-			q:       mockQueue,
+		app := NewApp(mockStorage, WithQueue(mockQueue))
+		mockStorage.addTasksFunc = func(tasks []model.Task) ([]int64, error) {
+			return nil, errors.New("add tasks error")
 		}
-		mockStorage.getAllFunc = func() ([]model.Task, error) {
-			return nil, errors.New("get all tasks error")
+		mockQueue.enqueueFunc = func(task model.Task) {
+			t.Error("Task was enqueued after a failed insert")
 		}
-		_, err := app.GetAllTasks()
+		_, err := app.CreateTasks([]model.Task{{Method: "GET", URL: "https://example.com"}})
 		if err == nil {
 			t.Error("Expected error, got nil")
 		}
@@ -323,7 +548,7 @@ func TestGetTaskByID(t *testing.T) {
 			return expectedTask, nil
 		}
 
-		task, err := app.GetTaskByID(123)
+		task, err := app.GetTaskByID("team-a", 123)
 
 		if err != nil {
 			t.Errorf("Expected no error, got %v", err)
@@ -350,7 +575,7 @@ func TestGetTaskByID(t *testing.T) {
 			return model.Task{}, errors.New("task not found")
 		}
 
-		_, err := app.GetTaskByID(999)
+		_, err := app.GetTaskByID("team-a", 999)
 
 		if err == nil {
 			t.Error("Expected error, got nil")
@@ -372,7 +597,7 @@ func TestDeleteTaskByID(t *testing.T) {
 			}
 			return 1, nil
 		}
-		count, err := app.DeleteTaskByID(123)
+		count, err := app.DeleteTaskByID("team-a", 123)
 		if err != nil {
 			t.Errorf("Expected no error, got %v", err)
 		}
@@ -391,7 +616,7 @@ func TestDeleteTaskByID(t *testing.T) {
 		mockStorage.deleteFunc = func(id int64) (int64, error) {
 			return 0, errors.New("delete error")
 		}
-		_, err := app.DeleteTaskByID(999)
+		_, err := app.DeleteTaskByID("team-a", 999)
 		if err == nil {
 			t.Error("Expected error, got nil")
 		}
@@ -412,7 +637,7 @@ func TestCleanStorage(t *testing.T) {
 			return nil
 		}
 
-		err := app.CleanStorage()
+		err := app.CleanStorage("team-a")
 		if err != nil {
 			t.Errorf("Expected no error, got %v", err)
 		}
@@ -432,7 +657,7 @@ func TestCleanStorage(t *testing.T) {
 			return errors.New("clean error")
 		}
 
-		err := app.CleanStorage()
+		err := app.CleanStorage("team-a")
 
 		if err == nil {
 			t.Error("Expected error, got nil")
@@ -519,3 +744,420 @@ func TestInitworkersTaskSendError(t *testing.T) {
 	}
 	t.Log("Note: Cannot fully test error handling without mocking HTTPclient")
 }
+
+func TestInitworkersRetry(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		n := requests
+		mu.Unlock()
+		if n < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	app := &App{
+		storage: mockStorage,
+		q:       mockQueue,
+	}
+	var processFunc func(model.Task)
+	mockQueue.startFunc = func(num int, process func(model.Task)) {
+		processFunc = process
+	}
+	requeued := make(chan model.Task, 1)
+	mockQueue.enqueueFunc = func(task model.Task) {
+		requeued <- task
+	}
+	var statuses []string
+	mockStorage.updateFunc = func(task *model.Task, status string) error {
+		mu.Lock()
+		statuses = append(statuses, status)
+		mu.Unlock()
+		return nil
+	}
+	app.Initworkers(1)
+
+	task := model.Task{
+		ID:     1,
+		Method: "GET",
+		URL:    server.URL,
+		Retry:  &model.RetryPolicy{MaxAttempts: 3, BaseDelay: model.Duration(time.Millisecond)},
+	}
+	processFunc(task)
+	for i := 0; i < 2; i++ {
+		select {
+		case task = <-requeued:
+			if task.Attempts != i+1 {
+				t.Errorf("Expected %d attempts, got %d", i+1, task.Attempts)
+			}
+			if task.NextRetryAt == nil {
+				t.Error("Expected next retry time to be set")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Task was not re-enqueued")
+		}
+		processFunc(task)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 3 {
+		t.Errorf("Expected 3 requests, got %d", requests)
+	}
+	if statuses[len(statuses)-1] != model.Done {
+		t.Errorf("Expected final status %s, got %s", model.Done, statuses[len(statuses)-1])
+	}
+	for _, status := range statuses[:len(statuses)-1] {
+		if status == model.Done || status == model.Error {
+			t.Errorf("Final status %s set before retries ran out", status)
+		}
+	}
+}
+
+func TestInitworkersRetryExhausted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	app := &App{
+		storage: mockStorage,
+		q:       mockQueue,
+	}
+	var processFunc func(model.Task)
+	mockQueue.startFunc = func(num int, process func(model.Task)) {
+		processFunc = process
+	}
+	var finalStatus string
+	mockStorage.updateFunc = func(task *model.Task, status string) error {
+		finalStatus = status
+		return nil
+	}
+	app.Initworkers(1)
+
+	processFunc(model.Task{
+		ID:       1,
+		Method:   "GET",
+		URL:      server.URL,
+		Attempts: 1,
+		Retry:    &model.RetryPolicy{MaxAttempts: 2},
+	})
+
+	if finalStatus != model.Error {
+		t.Errorf("Expected status %s, got %s", model.Error, finalStatus)
+	}
+}
+
+func TestCreateScheduledTask(t *testing.T) {
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	app := NewApp(mockStorage, WithQueue(mockQueue))
+
+	var createdStatus string
+	mockStorage.updateFunc = func(task *model.Task, status string) error {
+		createdStatus = status
+		return nil
+	}
+	mockStorage.addTaskFunc = func(task model.Task) (int64, error) {
+		if task.RunAt == nil {
+			t.Error("Expected run_at to be set from delay")
+		}
+		return 5, nil
+	}
+	enqueued := make(chan model.Task, 1)
+	mockQueue.enqueueFunc = func(task model.Task) {
+		enqueued <- task
+	}
+	app.Initworkers(1)
+
+	_, err := app.CreateTask(model.Task{
+		Method: "GET",
+		URL:    "https://example.com",
+		Delay:  model.Duration(50 * time.Millisecond),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if createdStatus != model.Scheduled {
+		t.Errorf("Expected status %s, got %s", model.Scheduled, createdStatus)
+	}
+
+	select {
+	case <-enqueued:
+		t.Fatal("Scheduled task was enqueued before its time")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	select {
+	case task := <-enqueued:
+		if task.ID != 5 {
+			t.Errorf("Expected task ID 5, got %d", task.ID)
+		}
+		if task.Status != model.New {
+			t.Errorf("Expected status %s, got %s", model.New, task.Status)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Scheduled task was not enqueued")
+	}
+}
+
+func TestRestoreScheduledTasks(t *testing.T) {
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	app := NewApp(mockStorage, WithQueue(mockQueue))
+
+	runAt := time.Now().Add(-time.Second)
+	mockStorage.getByStatus = func(status string) ([]model.Task, error) {
+		if status != model.Scheduled {
+			t.Errorf("Expected status %s, got %s", model.Scheduled, status)
+		}
+		return []model.Task{{ID: 9, Status: model.Scheduled, RunAt: &runAt}}, nil
+	}
+	enqueued := make(chan model.Task, 1)
+	mockQueue.enqueueFunc = func(task model.Task) {
+		enqueued <- task
+	}
+	app.Initworkers(1)
+
+	select {
+	case task := <-enqueued:
+		if task.ID != 9 {
+			t.Errorf("Expected task ID 9, got %d", task.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Restored task was not enqueued")
+	}
+}
+
+type MockClient struct {
+	sendFunc func(ctx context.Context, task *model.Task) (*model.ResponseData, error)
+}
+
+func (m *MockClient) SendTask(ctx context.Context, task *model.Task) (*model.ResponseData, error) {
+	if m.sendFunc != nil {
+		return m.sendFunc(ctx, task)
+	}
+	return &model.ResponseData{Status: "200 OK", StatusCode: http.StatusOK}, nil
+}
+
+func TestInitworkersClientOptions(t *testing.T) {
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	var receivedOptions *model.RequestOptions
+	app := NewApp(mockStorage, WithQueue(mockQueue), WithClientFactory(func(options *model.RequestOptions) client.Client {
+		receivedOptions = options
+		return &MockClient{}
+	}))
+	var finalStatus string
+	mockStorage.updateFunc = func(task *model.Task, status string) error {
+		finalStatus = status
+		return nil
+	}
+	app.Initworkers(1)
+
+	options := &model.RequestOptions{Timeout: model.Duration(time.Minute), Redirects: model.RedirectNone}
+	mockQueue.processFunc(model.Task{ID: 1, Method: "GET", URL: "https://example.com", Options: options})
+
+	if receivedOptions != options {
+		t.Errorf("Expected client built from task options, got %+v", receivedOptions)
+	}
+	if finalStatus != model.Done {
+		t.Errorf("Expected status %s, got %s", model.Done, finalStatus)
+	}
+}
+
+func TestInitworkersAssertions(t *testing.T) {
+	tests := []struct {
+		name       string
+		assertions *model.Assertions
+		expected   string
+	}{
+		{"passed", &model.Assertions{Status: []string{"2xx"}}, model.Done},
+		{"failed", &model.Assertions{Status: []string{"201"}}, model.Error},
+		{"no assertions", nil, model.Done},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := &MockStorage{}
+			mockQueue := &MockTaskQueue{}
+			app := NewApp(mockStorage, WithQueue(mockQueue), WithClientFactory(func(options *model.RequestOptions) client.Client {
+				return &MockClient{}
+			}))
+			var finalStatus string
+			mockStorage.updateFunc = func(task *model.Task, status string) error {
+				finalStatus = status
+				return nil
+			}
+			var results []model.AssertionResult
+			mockStorage.assertionsFunc = func(task *model.Task) error {
+				results = task.AssertionResults
+				return nil
+			}
+			app.Initworkers(1)
+
+			mockQueue.processFunc(model.Task{ID: 1, Method: "GET", URL: "https://example.com", Assertions: tt.assertions})
+
+			if finalStatus != tt.expected {
+				t.Errorf("Expected status %s, got %s", tt.expected, finalStatus)
+			}
+			if tt.assertions != nil && len(results) != 1 {
+				t.Errorf("Expected 1 stored assertion result, got %v", results)
+			}
+		})
+	}
+}
+
+func TestInitworkersRecordsAttempt(t *testing.T) {
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	app := NewApp(mockStorage, WithQueue(mockQueue), WithClientFactory(func(options *model.RequestOptions) client.Client {
+		return &MockClient{sendFunc: func(ctx context.Context, task *model.Task) (*model.ResponseData, error) {
+			return nil, errors.New("connection refused")
+		}}
+	}))
+	var attempts []model.TaskAttempt
+	mockStorage.addAttemptFunc = func(attempt model.TaskAttempt) error {
+		attempts = append(attempts, attempt)
+		return nil
+	}
+	app.Initworkers(1)
+
+	body := &model.Body{Type: model.BodyJSON, Content: []byte(`{"a":1}`)}
+	mockQueue.processFunc(model.Task{ID: 2, Method: "POST", URL: "https://example.com", Body: body})
+
+	if len(attempts) != 1 {
+		t.Fatalf("Expected 1 recorded attempt, got %d", len(attempts))
+	}
+	attempt := attempts[0]
+	if attempt.TaskID != 2 || attempt.Attempt != 1 || attempt.Error != "connection refused" || attempt.Response != nil {
+		t.Errorf("Unexpected attempt %+v", attempt)
+	}
+	if attempt.Request.Headers.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected the default Content-Type in the recorded request, got %v", attempt.Request.Headers)
+	}
+	if attempt.FinishedAt.Before(attempt.StartedAt) {
+		t.Errorf("Attempt finished at %s before it started at %s", attempt.FinishedAt, attempt.StartedAt)
+	}
+}
+
+func TestInitworkersDeferOpenCircuit(t *testing.T) {
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	app := NewApp(mockStorage, WithQueue(mockQueue), WithClientFactory(func(options *model.RequestOptions) client.Client {
+		return &MockClient{sendFunc: func(ctx context.Context, task *model.Task) (*model.ResponseData, error) {
+			return nil, &HTTPclient.CircuitOpenError{Host: "example.com", RetryAt: time.Now().Add(10 * time.Millisecond)}
+		}}
+	}))
+	var finalStatus string
+	mockStorage.updateFunc = func(task *model.Task, status string) error {
+		finalStatus = status
+		return nil
+	}
+	recorded := false
+	mockStorage.addAttemptFunc = func(attempt model.TaskAttempt) error {
+		recorded = true
+		return nil
+	}
+	requeued := make(chan model.Task, 1)
+	app.Initworkers(1)
+	mockQueue.enqueueFunc = func(task model.Task) {
+		requeued <- task
+	}
+
+	mockQueue.processFunc(model.Task{ID: 1, Method: "GET", URL: "https://example.com", Attempts: 1})
+
+	if finalStatus != model.Retrying {
+		t.Errorf("Expected status %s, got %s", model.Retrying, finalStatus)
+	}
+	if recorded {
+		t.Error("Expected no attempt to be recorded for a request that was not sent")
+	}
+	select {
+	case task := <-requeued:
+		if task.Attempts != 1 {
+			t.Errorf("Expected the deferral not to count as an attempt, got %d attempts", task.Attempts)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Deferred task was not requeued")
+	}
+}
+
+func TestInitworkersDeferRateLimited(t *testing.T) {
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	limiter := ratelimit.New([]model.RateLimit{{Pattern: "example.com", MaxConcurrent: 1}})
+	sent := 0
+	app := NewApp(mockStorage, WithQueue(mockQueue), WithRateLimiter(limiter), WithClientFactory(func(options *model.RequestOptions) client.Client {
+		return &MockClient{sendFunc: func(ctx context.Context, task *model.Task) (*model.ResponseData, error) {
+			sent++
+			return &model.ResponseData{StatusCode: 200}, nil
+		}}
+	}))
+	var statuses []string
+	mockStorage.updateFunc = func(task *model.Task, status string) error {
+		statuses = append(statuses, status)
+		return nil
+	}
+	leased := false
+	mockStorage.leaseFunc = func(task *model.Task, owner string, expiresAt time.Time) error {
+		leased = true
+		return nil
+	}
+	cancelled := false
+	mockStorage.updateIfFunc = func(task *model.Task, current string, status string) (bool, error) {
+		if cancelled {
+			return false, nil
+		}
+		statuses = append(statuses, status)
+		return true, nil
+	}
+	requeued := make(chan model.Task, 1)
+	app.Initworkers(1)
+	mockQueue.enqueueFunc = func(task model.Task) {
+		requeued <- task
+	}
+
+	// Another request to the host is in flight.
+	release, _ := limiter.TryAcquire("example.com")
+	mockQueue.processFunc(model.Task{ID: 1, Status: model.New, Method: "GET", URL: "https://example.com", Attempts: 1})
+	// A task claimed by the PostgreSQL queue is released, unless it was
+	// cancelled in the meantime.
+	mockQueue.processFunc(model.Task{ID: 2, Status: model.In_process, Method: "GET", URL: "https://example.com", Attempts: 1})
+	cancelled = true
+	mockQueue.processFunc(model.Task{ID: 3, Status: model.In_process, Method: "GET", URL: "https://example.com", Attempts: 1})
+	release()
+
+	if sent != 0 {
+		t.Errorf("Expected no request over the rate limit, got %d", sent)
+	}
+	if leased {
+		t.Error("Expected no lease for a deferred task")
+	}
+	if !slices.Equal(statuses, []string{model.New}) {
+		t.Errorf("Expected only the claimed task to go back to %s, got statuses %v", model.New, statuses)
+	}
+	for _, id := range []int64{1, 2} {
+		select {
+		case task := <-requeued:
+			if task.Attempts != 1 {
+				t.Errorf("Expected the deferral not to count as an attempt, got %d attempts", task.Attempts)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Deferred task %d was not requeued", id)
+		}
+	}
+	select {
+	case task := <-requeued:
+		t.Errorf("Expected the cancelled task not to be requeued, got %+v", task)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
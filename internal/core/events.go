@@ -0,0 +1,26 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/events"
+	"MyFirstGoApp/internal/model"
+)
+
+// WithEvents replaces the default event broker.
+func WithEvents(broker *events.Broker) Option {
+	return func(a *App) {
+		a.events = broker
+	}
+}
+
+func (a *App) publish(eventType string, task model.Task) {
+	if a.events != nil {
+		a.events.Publish(eventType, task)
+	}
+}
+
+// SubscribeEvents streams the status changes and stored responses of the
+// tasks of the tenant matching the filter. The subscription ends on Shutdown.
+func (a *App) SubscribeEvents(tenant string, filter events.Filter, lastEventID string) *events.Subscription {
+	filter.Tenant = tenant
+	return a.events.Subscribe(filter, lastEventID)
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/client"
+	"MyFirstGoApp/internal/events"
+	"MyFirstGoApp/internal/model"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestProcessTaskPublishesEvents(t *testing.T) {
+	mockQueue := &MockTaskQueue{}
+	app := NewApp(&MockStorage{}, WithQueue(mockQueue), WithClientFactory(func(options *model.RequestOptions) client.Client {
+		return &MockClient{sendFunc: func(ctx context.Context, task *model.Task) (*model.ResponseData, error) {
+			return &model.ResponseData{StatusCode: 200}, nil
+		}}
+	}))
+	app.Initworkers(1)
+	sub := app.SubscribeEvents("team-a", events.Filter{IDs: []int64{1}}, "")
+	defer sub.Close()
+
+	mockQueue.processFunc(model.Task{ID: 2, Tenant: "team-a", Method: "GET", URL: "https://example.com"})
+	mockQueue.processFunc(model.Task{ID: 1, Tenant: "team-a", Method: "GET", URL: "https://example.com"})
+
+	expected := []struct {
+		eventType string
+		status    string
+	}{
+		{events.StatusChanged, model.In_process},
+		{events.StatusChanged, model.Done},
+		{events.ResponseStored, model.Done},
+	}
+	for _, want := range expected {
+		select {
+		case event := <-sub.Events():
+			if event.Task.ID != 1 || event.Type != want.eventType || event.Task.Status != want.status {
+				t.Errorf("Expected %s event with status %s for task 1, got %s event with status %s for task %d",
+					want.eventType, want.status, event.Type, event.Task.Status, event.Task.ID)
+			}
+			if event.Type == events.ResponseStored && event.Task.Response.StatusCode != 200 {
+				t.Errorf("Expected the stored response in the event, got %+v", event.Task.Response)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected %s event", want.eventType)
+		}
+	}
+
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := <-sub.Events(); ok {
+		t.Error("Expected the subscription to end on shutdown")
+	}
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/storage"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrIdempotencyConflict = errors.New("idempotency key is already used for a different request")
+
+// CreateTaskIdempotent creates a task once per idempotency key. A repeated
+// request with the same key and payload gets the ID of the original task and
+// created set to false; a repeat with a different payload gets
+// ErrIdempotencyConflict.
+func (a *App) CreateTaskIdempotent(task model.Task, key string) (id int64, created bool, err error) {
+	hash, err := requestHash(task)
+	if err != nil {
+		return 0, false, err
+	}
+
+	id, err = a.lookupIdempotent(task.Tenant, key, hash)
+	if err == nil {
+		return id, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, err
+	}
+
+	task.IdempotencyKey = key
+	task.RequestHash = hash
+	id, err = a.CreateTask(task)
+	if errors.Is(err, storage.ErrDuplicateKey) {
+		// Another request with the same key was stored in the meantime.
+		id, err = a.lookupIdempotent(task.Tenant, key, hash)
+		return id, false, err
+	}
+	return id, err == nil, err
+}
+
+func (a *App) lookupIdempotent(tenant string, key string, hash string) (int64, error) {
+	existing, err := a.storage.GetTaskByIdempotencyKey(tenant, key)
+	if err != nil {
+		return 0, err
+	}
+	if existing.RequestHash != hash {
+		return 0, ErrIdempotencyConflict
+	}
+	return existing.ID, nil
+}
+
+// requestHash fingerprints the payload of a create request. The task is
+// hashed after decoding so that formatting and key order do not matter, and
+// without the fields the server sets from the credentials, so that a retry
+// with another key or token of the tenant matches the original request.
+func requestHash(task model.Task) (string, error) {
+	task.CreatedBy = ""
+	task.Tenant = ""
+	data, err := json.Marshal(task)
+	if err != nil {
+		return "", fmt.Errorf("hashing the request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/storage"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestCreateTaskIdempotent(t *testing.T) {
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	app := NewApp(mockStorage, WithQueue(mockQueue))
+
+	stored := map[string]model.Task{}
+	added := 0
+	mockStorage.addTaskFunc = func(task model.Task) (int64, error) {
+		added++
+		task.ID = int64(added)
+		stored[task.IdempotencyKey] = task
+		return task.ID, nil
+	}
+	mockStorage.getByKeyFunc = func(key string) (model.Task, error) {
+		task, ok := stored[key]
+		if !ok {
+			return model.Task{}, sql.ErrNoRows
+		}
+		return task, nil
+	}
+
+	task := model.Task{Method: "GET", URL: "https://example.com"}
+	id, created, err := app.CreateTaskIdempotent(task, "key-1")
+	if err != nil || !created || id != 1 {
+		t.Fatalf("Expected new task 1, got id %d, created %v, err %v", id, created, err)
+	}
+
+	id, created, err = app.CreateTaskIdempotent(task, "key-1")
+	if err != nil || created || id != 1 {
+		t.Errorf("Expected replay of task 1, got id %d, created %v, err %v", id, created, err)
+	}
+	task.CreatedBy = "apikey:7"
+	id, created, err = app.CreateTaskIdempotent(task, "key-1")
+	if err != nil || created || id != 1 {
+		t.Errorf("Expected replay of task 1 for another credential, got id %d, created %v, err %v", id, created, err)
+	}
+	if added != 1 {
+		t.Errorf("Expected 1 stored task, got %d", added)
+	}
+
+	task.URL = "https://example.org"
+	if _, _, err = app.CreateTaskIdempotent(task, "key-1"); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Errorf("Expected ErrIdempotencyConflict, got %v", err)
+	}
+}
+
+func TestCreateTaskIdempotentConcurrentInsert(t *testing.T) {
+	mockStorage := &MockStorage{}
+	app := NewApp(mockStorage, WithQueue(&MockTaskQueue{}))
+
+	task := model.Task{Method: "GET", URL: "https://example.com"}
+	hash, err := requestHash(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookups := 0
+	mockStorage.getByKeyFunc = func(key string) (model.Task, error) {
+		lookups++
+		if lookups == 1 {
+			return model.Task{}, sql.ErrNoRows
+		}
+		return model.Task{ID: 5, RequestHash: hash}, nil
+	}
+	mockStorage.addTaskFunc = func(task model.Task) (int64, error) {
+		return 0, storage.ErrDuplicateKey
+	}
+
+	id, created, err := app.CreateTaskIdempotent(task, "key-1")
+	if err != nil || created || id != 5 {
+		t.Errorf("Expected replay of task 5, got id %d, created %v, err %v", id, created, err)
+	}
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/events"
+	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/queue"
+	"MyFirstGoApp/internal/retry"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// DefaultLeaseTimeout is how long a task in process stays with its worker
+// without a heartbeat before the reaper takes it back.
+const DefaultLeaseTimeout = 30 * time.Second
+
+// WithLeaseTimeout sets the lease timeout of tasks in process. Workers renew
+// their leases three times per timeout, and the reaper looks for expired
+// leases once per timeout.
+func WithLeaseTimeout(timeout time.Duration) Option {
+	return func(a *App) {
+		a.leaseTimeout = timeout
+	}
+}
+
+// newInstanceID names this instance as the owner of its leases.
+func newInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+func (a *App) leaseDuration() time.Duration {
+	if a.leaseTimeout <= 0 {
+		return DefaultLeaseTimeout
+	}
+	return a.leaseTimeout
+}
+
+// takeLease makes this instance the owner of a task it starts working on.
+func (a *App) takeLease(task *model.Task) {
+	err := a.storage.UpdateTaskLease(task, a.instanceID, time.Now().Add(a.leaseDuration()))
+	if err != nil {
+		log.Printf("Error taking the lease of task with ID %d: %v\n", task.ID, err)
+	}
+}
+
+// runHeartbeats renews the leases of the tasks in flight until Shutdown.
+func (a *App) runHeartbeats() {
+	ticker := time.NewTicker(a.leaseDuration() / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.stopped:
+			return
+		case <-ticker.C:
+			a.renewLeases()
+		}
+	}
+}
+
+func (a *App) renewLeases() {
+	a.mu.Lock()
+	ids := make([]int64, 0, len(a.inFlight))
+	for id := range a.inFlight {
+		ids = append(ids, id)
+	}
+	a.mu.Unlock()
+	if len(ids) == 0 {
+		return
+	}
+
+	err := a.storage.ExtendTaskLeases(a.instanceID, ids, time.Now().Add(a.leaseDuration()))
+	if err != nil {
+		log.Printf("Error renewing the leases of %d tasks: %v\n", len(ids), err)
+	}
+}
+
+// runReaper recovers the tasks of crashed workers, once at startup and then
+// once per lease timeout, until Shutdown.
+func (a *App) runReaper() {
+	a.reapExpired(time.Now())
+	ticker := time.NewTicker(a.leaseDuration())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.stopped:
+			return
+		case <-ticker.C:
+			a.reapExpired(time.Now())
+		}
+	}
+}
+
+// reapExpired takes back the tasks in process whose lease has expired. A task
+// that was never sent goes back to new; one whose worker died mid-request is
+// retried if its retry policy allows another attempt and fails otherwise.
+func (a *App) reapExpired(now time.Time) {
+	tasks, err := a.storage.GetExpiredTasks(now)
+	if err != nil {
+		log.Printf("Error loading tasks with expired leases: %v\n", err)
+		return
+	}
+
+	for _, task := range tasks {
+		status := model.New
+		var delay time.Duration
+		if task.Attempts > 0 {
+			status = model.Error
+			if task.Retry != nil && task.Attempts < task.Retry.MaxAttempts {
+				status = model.Retrying
+				delay = retry.Delay(task.Retry, task.Attempts)
+				nextRetryAt := now.Add(delay)
+				task.NextRetryAt = &nextRetryAt
+			}
+		}
+
+		owner := task.LeaseOwner
+		task.StampStatus(status, now)
+		reclaimed, err := a.storage.ReclaimTask(&task, status)
+		if err != nil {
+			log.Printf("Error reclaiming task with ID %d: %v\n", task.ID, err)
+			continue
+		}
+		if !reclaimed {
+			// The owner renewed the lease or another instance was faster.
+			continue
+		}
+		log.Printf("Lease of task with ID %d held by %q expired, task set to %s\n", task.ID, owner, status)
+		a.publish(events.StatusChanged, task)
+
+		if status == model.Error {
+			a.notifyFinished(task)
+			continue
+		}
+		time.AfterFunc(delay, func() {
+			a.enqueue(task)
+		})
+	}
+}
+
+// recoverPending refills a queue that keeps its tasks in memory with the new
+// and retrying tasks left in storage by the previous run.
+func (a *App) recoverPending() {
+	if durable, ok := a.q.(queue.Durable); ok && durable.Durable() {
+		return
+	}
+	tasks, err := a.storage.GetPendingTasks()
+	if err != nil {
+		log.Printf("Error loading pending tasks: %v\n", err)
+		return
+	}
+	if len(tasks) > 0 {
+		log.Printf("Recovering %d pending tasks\n", len(tasks))
+	}
+
+	for _, task := range tasks {
+		var delay time.Duration
+		if task.NextRetryAt != nil {
+			delay = time.Until(*task.NextRetryAt)
+		}
+		time.AfterFunc(delay, func() {
+			a.enqueue(task)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/model"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReapExpired(t *testing.T) {
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	notifier := &MockNotifier{delivered: make(chan model.Task, 1)}
+	app := NewApp(mockStorage, WithQueue(mockQueue), WithNotifier(notifier))
+
+	expires := time.Now().Add(-time.Minute)
+	mockStorage.expiredFunc = func(now time.Time) ([]model.Task, error) {
+		return []model.Task{
+			{ID: 1, Status: model.In_process, LeaseExpiresAt: &expires},
+			{ID: 2, Status: model.In_process, Attempts: 1, LeaseExpiresAt: &expires,
+				Retry: &model.RetryPolicy{MaxAttempts: 3, BaseDelay: model.Duration(time.Millisecond)}},
+			{ID: 3, Status: model.In_process, Attempts: 1, LeaseExpiresAt: &expires, CallbackURL: "https://example.com/hook"},
+			{ID: 4, Status: model.In_process, Attempts: 1, LeaseExpiresAt: &expires},
+		}, nil
+	}
+	var mu sync.Mutex
+	reclaimed := make(map[int64]string)
+	mockStorage.reclaimFunc = func(task *model.Task, status string) (bool, error) {
+		if task.ID == 4 {
+			// The owner renewed the lease in the meantime.
+			return false, nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		reclaimed[task.ID] = status
+		task.Status = status
+		return true, nil
+	}
+	enqueued := make(chan model.Task, 2)
+	mockQueue.enqueueFunc = func(task model.Task) {
+		enqueued <- task
+	}
+
+	app.reapExpired(time.Now())
+
+	mu.Lock()
+	if reclaimed[1] != model.New {
+		t.Errorf("Expected a task without attempts to be reclaimed as new, got %q", reclaimed[1])
+	}
+	if reclaimed[2] != model.Retrying {
+		t.Errorf("Expected a task with attempts left to be reclaimed as retrying, got %q", reclaimed[2])
+	}
+	if reclaimed[3] != model.Error {
+		t.Errorf("Expected a task without attempts left to be reclaimed as error, got %q", reclaimed[3])
+	}
+	if _, ok := reclaimed[4]; ok {
+		t.Error("Expected a renewed task not to be reclaimed")
+	}
+	mu.Unlock()
+
+	ids := make(map[int64]bool)
+	for range 2 {
+		select {
+		case task := <-enqueued:
+			ids[task.ID] = true
+		case <-time.After(time.Second):
+			t.Fatal("Reclaimed task was not enqueued")
+		}
+	}
+	if !ids[1] || !ids[2] {
+		t.Errorf("Expected tasks 1 and 2 to be enqueued, got %v", ids)
+	}
+
+	select {
+	case task := <-notifier.delivered:
+		if task.ID != 3 || task.Status != model.Error {
+			t.Errorf("Expected notification for failed task 3, got task %d with status %q", task.ID, task.Status)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Failed task was not notified")
+	}
+}
+
+func TestRecoverPending(t *testing.T) {
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	app := NewApp(mockStorage, WithQueue(mockQueue))
+
+	mockStorage.pendingFunc = func() ([]model.Task, error) {
+		nextRetryAt := time.Now().Add(10 * time.Millisecond)
+		return []model.Task{
+			{ID: 1, Status: model.New},
+			{ID: 2, Status: model.Retrying, NextRetryAt: &nextRetryAt},
+		}, nil
+	}
+	enqueued := make(chan model.Task, 2)
+	mockQueue.enqueueFunc = func(task model.Task) {
+		enqueued <- task
+	}
+
+	app.recoverPending()
+
+	for _, id := range []int64{1, 2} {
+		select {
+		case task := <-enqueued:
+			if task.ID != id {
+				t.Errorf("Expected task %d to be enqueued, got %d", id, task.ID)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Task %d was not enqueued", id)
+		}
+	}
+}
+
+func TestProcessTaskTakesLease(t *testing.T) {
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	app := NewApp(mockStorage, WithQueue(mockQueue), WithLeaseTimeout(time.Minute))
+
+	var owner string
+	var expiresAt time.Time
+	mockStorage.leaseFunc = func(task *model.Task, o string, e time.Time) error {
+		owner, expiresAt = o, e
+		return nil
+	}
+	app.Initworkers(1)
+	mockQueue.processFunc(model.Task{ID: 1, Method: "GET", URL: "http://127.0.0.1:1"})
+
+	if owner != app.instanceID || owner == "" {
+		t.Errorf("Expected lease owner %q, got %q", app.instanceID, owner)
+	}
+	if until := time.Until(expiresAt); until < 50*time.Second || until > time.Minute {
+		t.Errorf("Expected lease to expire in about a minute, got %s", until)
+	}
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/events"
+	"MyFirstGoApp/internal/model"
+	"time"
+)
+
+// setStatus moves the task to the status and stamps the time it got there.
+// Every status change of a task goes through setStatus or setStatusIf, so
+// that its timestamps and transition history stay complete and every change
+// reaches the event stream.
+func (a *App) setStatus(task *model.Task, status string) error {
+	task.StampStatus(status, time.Now())
+	err := a.storage.UpdateTaskStatus(task, status)
+	if err == nil {
+		task.Status = status
+		a.publish(events.StatusChanged, *task)
+	}
+	return err
+}
+
+// setStatusIf is setStatus for a task that must still have the current
+// status. The task is left untouched when it does not.
+func (a *App) setStatusIf(task *model.Task, current string, status string) (bool, error) {
+	stamped := *task
+	stamped.StampStatus(status, time.Now())
+	updated, err := a.storage.UpdateTaskStatusIf(&stamped, current, status)
+	if updated {
+		stamped.Status = status
+		*task = stamped
+		a.publish(events.StatusChanged, *task)
+	}
+	return updated, err
+}
+
+// GetTaskTransitions returns the status history of a task, oldest first.
+func (a *App) GetTaskTransitions(tenant string, id int64) ([]model.TaskTransition, error) {
+	if _, err := a.storage.GetTaskByID(tenant, id); err != nil {
+		return nil, err
+	}
+	return a.storage.GetTaskTransitions(tenant, id)
+}
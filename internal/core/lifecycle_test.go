@@ -0,0 +1,65 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/client"
+	"MyFirstGoApp/internal/model"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestProcessTaskStampsLifecycle(t *testing.T) {
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	app := NewApp(mockStorage, WithQueue(mockQueue), WithClientFactory(func(options *model.RequestOptions) client.Client {
+		return &MockClient{sendFunc: func(ctx context.Context, task *model.Task) (*model.ResponseData, error) {
+			time.Sleep(5 * time.Millisecond)
+			return &model.ResponseData{StatusCode: 200}, nil
+		}}
+	}))
+	stamped := make(map[string]model.Task)
+	mockStorage.updateFunc = func(task *model.Task, status string) error {
+		task.Status = status
+		stamped[status] = *task
+		return nil
+	}
+	app.Initworkers(1)
+
+	queuedAt := time.Now().Add(-time.Second)
+	mockQueue.processFunc(model.Task{ID: 1, Method: "GET", URL: "https://example.com", Status: model.New, QueuedAt: &queuedAt})
+
+	started, ok := stamped[model.In_process]
+	if !ok || started.StartedAt == nil {
+		t.Fatal("Expected started_at to be set when the task went in process")
+	}
+	done, ok := stamped[model.Done]
+	if !ok || done.FinishedAt == nil {
+		t.Fatal("Expected finished_at to be set when the task was done")
+	}
+	if !done.StartedAt.Equal(*started.StartedAt) {
+		t.Errorf("Expected started_at to stay %s, got %s", started.StartedAt, done.StartedAt)
+	}
+	if !done.QueuedAt.Equal(queuedAt) {
+		t.Errorf("Expected queued_at to stay %s, got %s", queuedAt, done.QueuedAt)
+	}
+	if want := model.Duration(done.FinishedAt.Sub(*done.StartedAt)); done.Duration != want || done.Duration <= 0 {
+		t.Errorf("Expected duration %s, got %s", time.Duration(want), time.Duration(done.Duration))
+	}
+}
+
+func TestSetStatusIfLeavesTaskOnConflict(t *testing.T) {
+	mockStorage := &MockStorage{}
+	app := NewApp(mockStorage, WithQueue(&MockTaskQueue{}))
+	mockStorage.updateIfFunc = func(task *model.Task, current string, status string) (bool, error) {
+		return false, nil
+	}
+
+	task := model.Task{ID: 1, Status: model.Scheduled}
+	updated, err := app.setStatusIf(&task, model.Scheduled, model.New)
+	if err != nil || updated {
+		t.Fatalf("Expected no update and no error, got %v, %v", updated, err)
+	}
+	if task.QueuedAt != nil || task.Status != model.Scheduled {
+		t.Errorf("Expected the task to be left untouched, got %+v", task)
+	}
+}
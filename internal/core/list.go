@@ -0,0 +1,42 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/storage"
+)
+
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+// ListTasks returns one page of the tasks matching the query and the cursor
+// of the next page, which is nil on the last page. The page size defaults to
+// DefaultPageSize and is capped at MaxPageSize.
+func (a *App) ListTasks(query storage.TaskQuery) ([]model.Task, *storage.TaskCursor, error) {
+	if query.Limit <= 0 {
+		query.Limit = DefaultPageSize
+	}
+	if query.Limit > MaxPageSize {
+		query.Limit = MaxPageSize
+	}
+	limit := query.Limit
+	// One task more tells whether there is a next page.
+	query.Limit++
+
+	tasks, err := a.storage.QueryTasks(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tasks) <= limit {
+		return tasks, nil, nil
+	}
+
+	tasks = tasks[:limit]
+	last := tasks[limit-1]
+	cursor := &storage.TaskCursor{ID: last.ID, Priority: last.Priority}
+	if last.CreatedAt != nil {
+		cursor.CreatedAt = *last.CreatedAt
+	}
+	return tasks, cursor, nil
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestListTasks(t *testing.T) {
+	mockStorage := &MockStorage{}
+	app := NewApp(mockStorage, WithQueue(&MockTaskQueue{}))
+
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var gotLimit int
+	mockStorage.queryFunc = func(query storage.TaskQuery) ([]model.Task, error) {
+		gotLimit = query.Limit
+		var tasks []model.Task
+		for i := 1; i <= min(query.Limit, 3); i++ {
+			tasks = append(tasks, model.Task{ID: int64(i), Priority: i, CreatedAt: &createdAt})
+		}
+		return tasks, nil
+	}
+
+	t.Run("Next page", func(t *testing.T) {
+		tasks, cursor, err := app.ListTasks(storage.TaskQuery{Limit: 2})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if gotLimit != 3 {
+			t.Errorf("Expected one task more to be queried, got limit %d", gotLimit)
+		}
+		if len(tasks) != 2 {
+			t.Fatalf("Expected 2 tasks, got %d", len(tasks))
+		}
+		if cursor == nil {
+			t.Fatal("Expected a cursor of the next page")
+		}
+		if cursor.ID != 2 || cursor.Priority != 2 || !cursor.CreatedAt.Equal(createdAt) {
+			t.Errorf("Expected cursor at task 2, got %+v", cursor)
+		}
+	})
+
+	t.Run("Last page", func(t *testing.T) {
+		tasks, cursor, err := app.ListTasks(storage.TaskQuery{Limit: 5})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(tasks) != 3 {
+			t.Errorf("Expected 3 tasks, got %d", len(tasks))
+		}
+		if cursor != nil {
+			t.Errorf("Expected no cursor on the last page, got %+v", cursor)
+		}
+	})
+
+	t.Run("Default and maximum page size", func(t *testing.T) {
+		app.ListTasks(storage.TaskQuery{})
+		if gotLimit != DefaultPageSize+1 {
+			t.Errorf("Expected default limit %d, got %d", DefaultPageSize+1, gotLimit)
+		}
+		app.ListTasks(storage.TaskQuery{Limit: 100000})
+		if gotLimit != MaxPageSize+1 {
+			t.Errorf("Expected maximum limit %d, got %d", MaxPageSize+1, gotLimit)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/queue"
+	"MyFirstGoApp/internal/quota"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInvalidQuota  = errors.New("invalid tenant quota")
+	ErrQuotaExceeded = errors.New("tenant quota exceeded")
+)
+
+// tenantDeferDelay is how long a task waits before it is tried again while
+// its tenant uses up its share of the workers.
+var tenantDeferDelay = time.Second
+
+// WithQuotas replaces the tenant quotas, which limit nothing by default.
+func WithQuotas(quotas *quota.Quotas) Option {
+	return func(a *App) {
+		a.quotas = quotas
+	}
+}
+
+// checkQueueDepth refuses adding tasks to a tenant that would then have more
+// unfinished tasks than its quota allows. Concurrent requests can overshoot
+// the quota by the tasks they add.
+func (a *App) checkQueueDepth(tenant string, adding int) error {
+	if a.quotas == nil {
+		return nil
+	}
+	limit := a.quotas.For(tenant).MaxQueued
+	if limit == 0 {
+		return nil
+	}
+	queued, err := a.storage.CountUnfinishedTasks(tenant)
+	if err != nil {
+		return fmt.Errorf("counting the unfinished tasks: %w", err)
+	}
+	if queued+adding > limit {
+		return fmt.Errorf("%w: tenant %s may have at most %d unfinished tasks, it has %d", ErrQuotaExceeded, tenant, limit, queued)
+	}
+	return nil
+}
+
+// acquireWorker takes a worker for a task of the tenant unless the tenant
+// uses its whole share of the workers. The returned function frees it and
+// lets the queue hand out the tasks of the tenant again.
+func (a *App) acquireWorker(tenant string) (func(), bool) {
+	if a.quotas == nil {
+		return func() {}, true
+	}
+	release, ok := a.quotas.AcquireWorker(tenant)
+	if !ok {
+		return nil, false
+	}
+	return func() {
+		release()
+		if limited, ok := a.q.(queue.TenantLimited); ok {
+			limited.Wake()
+		}
+	}, true
+}
+
+// deferForTenant puts a task back into the queue while its tenant uses its
+// whole share of the workers, so that the workers go to the other tenants.
+// Queues that implement queue.TenantLimited hold such tasks back themselves,
+// so this happens only when two workers take a task of the tenant at once,
+// or with a queue that cannot pass over tenants.
+func (a *App) deferForTenant(task model.Task) {
+	a.postpone(task, tenantDeferDelay)
+}
+
+func (a *App) GetQuotas() []model.TenantQuota {
+	return a.quotas.Quotas()
+}
+
+// SetQuota adds a tenant quota or replaces the one of the same tenant.
+func (a *App) SetQuota(tenantQuota model.TenantQuota) error {
+	if err := tenantQuota.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidQuota, err)
+	}
+	a.quotas.Set(tenantQuota)
+	return nil
+}
+
+// DeleteQuota removes the quota of the tenant and reports whether it existed.
+func (a *App) DeleteQuota(tenant string) bool {
+	return a.quotas.Delete(tenant)
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/client"
+	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/queue"
+	"MyFirstGoApp/internal/quota"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCreateTaskQueueDepthQuota(t *testing.T) {
+	mockStorage := &MockStorage{}
+	app := NewApp(mockStorage, WithQueue(&MockTaskQueue{}), WithQuotas(quota.New([]model.TenantQuota{
+		{Tenant: "team-a", MaxQueued: 2},
+	})))
+	queued := map[string]int{"team-a": 2, "team-b": 2}
+	mockStorage.countFunc = func(tenant string) (int, error) {
+		return queued[tenant], nil
+	}
+
+	_, err := app.CreateTask(model.Task{Tenant: "team-a", Method: "GET", URL: "https://example.com"})
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Expected ErrQuotaExceeded, got %v", err)
+	}
+	if _, err := app.CreateTask(model.Task{Tenant: "team-b", Method: "GET", URL: "https://example.com"}); err != nil {
+		t.Errorf("Expected a tenant without a quota not to be limited, got %v", err)
+	}
+
+	queued["team-a"] = 1
+	if _, err := app.CreateTask(model.Task{Tenant: "team-a", Method: "GET", URL: "https://example.com"}); err != nil {
+		t.Errorf("Expected no error below the quota, got %v", err)
+	}
+	tasks := []model.Task{
+		{Tenant: "team-a", Method: "GET", URL: "https://example.com"},
+		{Tenant: "team-a", Method: "GET", URL: "https://example.com"},
+	}
+	if _, err := app.CreateTasks(tasks); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Expected a batch over the quota to be refused, got %v", err)
+	}
+}
+
+func TestProcessTaskWorkerShare(t *testing.T) {
+	defer func(delay time.Duration) { tenantDeferDelay = delay }(tenantDeferDelay)
+	tenantDeferDelay = 10 * time.Millisecond
+
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	started := make(chan int64, 3)
+	unblock := make(chan struct{})
+	app := NewApp(mockStorage, WithQueue(mockQueue), WithQuotas(quota.New([]model.TenantQuota{
+		{Tenant: "team-a", WorkerShare: 0.5},
+	})), WithClientFactory(func(options *model.RequestOptions) client.Client {
+		return &MockClient{sendFunc: func(ctx context.Context, task *model.Task) (*model.ResponseData, error) {
+			started <- task.ID
+			if task.ID == 1 {
+				<-unblock
+			}
+			return &model.ResponseData{StatusCode: 200}, nil
+		}}
+	}))
+	requeued := make(chan model.Task, 1)
+	app.Initworkers(2)
+	mockQueue.enqueueFunc = func(task model.Task) {
+		requeued <- task
+	}
+
+	go mockQueue.processFunc(model.Task{ID: 1, Tenant: "team-a", Method: "GET", URL: "https://example.com"})
+	if id := <-started; id != 1 {
+		t.Fatalf("Expected task 1 to start, got %d", id)
+	}
+
+	mockQueue.processFunc(model.Task{ID: 2, Tenant: "team-a", Method: "GET", URL: "https://example.com"})
+	select {
+	case task := <-requeued:
+		if task.ID != 2 || task.Attempts != 0 {
+			t.Errorf("Expected task 2 to be deferred without an attempt, got %+v", task)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Deferred task was not requeued")
+	}
+
+	mockQueue.processFunc(model.Task{ID: 3, Tenant: "team-b", Method: "GET", URL: "https://example.com"})
+	if id := <-started; id != 3 {
+		t.Errorf("Expected the task of another tenant to start, got %d", id)
+	}
+	close(unblock)
+}
+
+func TestProcessTaskWorkerShareBacklog(t *testing.T) {
+	mockStorage := &MockStorage{}
+	started := make(chan model.Task, 100)
+	unblock := make(chan struct{})
+	app := NewApp(mockStorage, WithQueue(queue.NewPriorityQueue(100, time.Minute)), WithQuotas(quota.New([]model.TenantQuota{
+		{Tenant: "team-a", WorkerShare: 0.5},
+	})), WithClientFactory(func(options *model.RequestOptions) client.Client {
+		return &MockClient{sendFunc: func(ctx context.Context, task *model.Task) (*model.ResponseData, error) {
+			started <- *task
+			if task.Tenant == "team-a" {
+				<-unblock
+			}
+			return &model.ResponseData{StatusCode: 200}, nil
+		}}
+	}))
+	var mu sync.Mutex
+	deferred := 0
+	mockStorage.attemptsFunc = func(task *model.Task) error {
+		if task.NextRetryAt != nil {
+			mu.Lock()
+			deferred++
+			mu.Unlock()
+		}
+		return nil
+	}
+	app.Initworkers(2)
+	defer close(unblock)
+
+	for i := 1; i <= 50; i++ {
+		app.enqueue(model.Task{ID: int64(i), Tenant: "team-a", Method: "GET", URL: "https://example.com"})
+	}
+	if task := <-started; task.Tenant != "team-a" {
+		t.Fatalf("Expected a task of team-a to start, got %+v", task)
+	}
+
+	app.enqueue(model.Task{ID: 51, Tenant: "team-b", Method: "GET", URL: "https://example.com"})
+	select {
+	case task := <-started:
+		if task.ID != 51 {
+			t.Errorf("Expected the task of team-b to start, got %+v", task)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Task of team-b did not start while team-a used its share")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if deferred != 0 {
+		t.Errorf("Expected the backlog of team-a to wait in the queue, %d tasks were taken and deferred", deferred)
+	}
+}
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/ratelimit"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+var ErrInvalidRateLimit = errors.New("invalid rate limit")
+
+// WithRateLimiter replaces the limiter of outbound requests, which limits
+// nothing by default.
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(a *App) {
+		a.limiter = limiter
+	}
+}
+
+// acquireHost claims a request to the target host under its rate limit. The
+// returned function releases the concurrency slot. When the limit does not
+// allow the request yet, it returns nil and how long to wait instead, so that
+// a throttled host does not hold the worker.
+func (a *App) acquireHost(rawURL string) (func(), time.Duration) {
+	target, err := url.Parse(rawURL)
+	if a.limiter == nil || err != nil {
+		// An invalid URL is reported by the client.
+		return func() {}, 0
+	}
+	return a.limiter.TryAcquire(target.Hostname())
+}
+
+func (a *App) GetRateLimits() []model.RateLimit {
+	return a.limiter.Rules()
+}
+
+// SetRateLimit adds a rate limit or replaces the one with the same pattern.
+func (a *App) SetRateLimit(rule model.RateLimit) error {
+	if err := rule.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRateLimit, err)
+	}
+	a.limiter.SetRule(rule)
+	return nil
+}
+
+// DeleteRateLimit removes the rate limit with the pattern and reports whether
+// it existed.
+func (a *App) DeleteRateLimit(pattern string) bool {
+	return a.limiter.DeleteRule(pattern)
+}
@@ -0,0 +1,134 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/recurring"
+	"fmt"
+	"log"
+	"time"
+)
+
+// schedulePollInterval is how often the recurring schedules are checked.
+var schedulePollInterval = time.Second
+
+func (a *App) CreateSchedule(schedule model.Schedule) (int64, error) {
+	if err := recurring.Validate(schedule); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	if err := setNextRun(&schedule, time.Now()); err != nil {
+		return 0, err
+	}
+
+	id, err := a.storage.AddSchedule(schedule)
+	if err != nil {
+		return 0, fmt.Errorf("adding schedule to database error: %w", err)
+	}
+	log.Printf("Schedule created successfully with ID %d\n", id)
+	return id, nil
+}
+
+func (a *App) UpdateSchedule(schedule model.Schedule) error {
+	if err := recurring.Validate(schedule); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	if err := setNextRun(&schedule, time.Now()); err != nil {
+		return err
+	}
+	return a.storage.UpdateSchedule(schedule)
+}
+
+func (a *App) GetAllSchedules(tenant string) ([]model.Schedule, error) {
+	return a.storage.GetAllSchedules(tenant)
+}
+
+func (a *App) GetScheduleByID(tenant string, id int64) (model.Schedule, error) {
+	return a.storage.GetScheduleByID(tenant, id)
+}
+
+func (a *App) GetScheduleTasks(tenant string, id int64) ([]model.Task, error) {
+	if _, err := a.storage.GetScheduleByID(tenant, id); err != nil {
+		return nil, err
+	}
+	return a.storage.GetTasksBySchedule(tenant, id)
+}
+
+func (a *App) DeleteScheduleByID(tenant string, id int64) error {
+	return a.storage.DeleteScheduleByID(tenant, id)
+}
+
+func setNextRun(schedule *model.Schedule, now time.Time) error {
+	schedule.NextRunAt = nil
+	if !schedule.Enabled {
+		return nil
+	}
+	next, err := recurring.Next(*schedule, now)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	schedule.NextRunAt = &next
+	return nil
+}
+
+// runSchedules fires the due recurring schedules until the app is stopped.
+func (a *App) runSchedules() {
+	ticker := time.NewTicker(schedulePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.stopped:
+			return
+		case <-ticker.C:
+			a.fireDueSchedules(time.Now())
+		}
+	}
+}
+
+func (a *App) fireDueSchedules(now time.Time) {
+	schedules, err := a.storage.GetDueSchedules(now)
+	if err != nil {
+		log.Printf("Error loading due schedules: %v\n", err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		// Runs missed while the service was down are not caught up, the
+		// schedule fires once and moves on to its next time after now.
+		next, err := recurring.Next(schedule, now)
+		if err != nil {
+			log.Printf("Error computing the next run of schedule %d: %v\n", schedule.ID, err)
+			continue
+		}
+		claimed, err := a.storage.ClaimScheduleRun(schedule.ID, *schedule.NextRunAt, next)
+		if err != nil {
+			log.Printf("Error claiming run of schedule %d: %v\n", schedule.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		task := schedule.Task
+		task.ResetServerFields()
+		task.CreatedBy = schedule.Task.CreatedBy
+		task.ScheduleID = &schedule.ID
+		task.Tenant = schedule.Tenant
+		// The run is claimed already, so a firing that creates no task is
+		// recorded on the schedule rather than retried.
+		id, err := a.CreateTask(task)
+		if err != nil {
+			log.Printf("Error creating task for schedule %d: %v\n", schedule.ID, err)
+			a.setScheduleError(schedule, err.Error())
+			continue
+		}
+		log.Printf("Schedule %d fired, created task with ID %d\n", schedule.ID, id)
+		if schedule.LastError != "" {
+			a.setScheduleError(schedule, "")
+		}
+	}
+}
+
+func (a *App) setScheduleError(schedule model.Schedule, message string) {
+	if err := a.storage.SetScheduleError(schedule.ID, message); err != nil {
+		log.Printf("Error recording the last error of schedule %d: %v\n", schedule.ID, err)
+	}
+}
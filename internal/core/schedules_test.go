@@ -0,0 +1,105 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/model"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCreateScheduleValidation(t *testing.T) {
+	app := NewApp(&MockStorage{}, WithQueue(&MockTaskQueue{}))
+
+	_, err := app.CreateSchedule(model.Schedule{
+		Cron:    "not a cron",
+		Enabled: true,
+		Task:    model.Task{Method: "GET", URL: "https://example.com"},
+	})
+	if !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("Expected ErrInvalidSchedule, got %v", err)
+	}
+}
+
+func TestFireDueSchedules(t *testing.T) {
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	app := NewApp(mockStorage, WithQueue(mockQueue))
+
+	now := time.Date(2024, 5, 1, 10, 0, 30, 0, time.UTC)
+	prevRunAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	mockStorage.dueSchedules = func(at time.Time) ([]model.Schedule, error) {
+		return []model.Schedule{
+			{ID: 1, Cron: "*/5 * * * *", Enabled: true, NextRunAt: &prevRunAt,
+				Task: model.Task{ID: 77, Method: "GET", URL: "https://example.com/1"}},
+			{ID: 2, Cron: "*/5 * * * *", Enabled: true, NextRunAt: &prevRunAt,
+				Task: model.Task{Method: "GET", URL: "https://example.com/2"}},
+		}, nil
+	}
+	mockStorage.claimSchedule = func(id int64, prev time.Time, next time.Time) (bool, error) {
+		if !prev.Equal(prevRunAt) {
+			t.Errorf("Expected previous run %s, got %s", prevRunAt, prev)
+		}
+		expectedNext := time.Date(2024, 5, 1, 10, 5, 0, 0, time.UTC)
+		if !next.Equal(expectedNext) {
+			t.Errorf("Expected next run %s, got %s", expectedNext, next)
+		}
+		// The second schedule has been fired by another instance.
+		return id == 1, nil
+	}
+	var created []model.Task
+	mockStorage.addTaskFunc = func(task model.Task) (int64, error) {
+		created = append(created, task)
+		return 100, nil
+	}
+
+	app.fireDueSchedules(now)
+
+	if len(created) != 1 {
+		t.Fatalf("Expected 1 created task, got %d", len(created))
+	}
+	if created[0].ScheduleID == nil || *created[0].ScheduleID != 1 {
+		t.Errorf("Expected task linked to schedule 1, got %v", created[0].ScheduleID)
+	}
+	if created[0].ID != 0 {
+		t.Errorf("Expected template ID to be reset, got %d", created[0].ID)
+	}
+	if len(mockQueue.tasks) != 1 || mockQueue.tasks[0].ID != 100 {
+		t.Errorf("Expected task 100 to be enqueued, got %v", mockQueue.tasks)
+	}
+}
+
+func TestFireDueSchedulesRecordsError(t *testing.T) {
+	mockStorage := &MockStorage{}
+	app := NewApp(mockStorage, WithQueue(&MockTaskQueue{}))
+
+	now := time.Date(2024, 5, 1, 10, 0, 30, 0, time.UTC)
+	prevRunAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	mockStorage.dueSchedules = func(at time.Time) ([]model.Schedule, error) {
+		return []model.Schedule{
+			{ID: 1, Cron: "*/5 * * * *", Enabled: true, NextRunAt: &prevRunAt,
+				Task: model.Task{Method: "GET", URL: "https://example.com/1"}},
+			{ID: 2, Cron: "*/5 * * * *", Enabled: true, NextRunAt: &prevRunAt, LastError: "database is down",
+				Task: model.Task{Method: "GET", URL: "https://example.com/2"}},
+		}, nil
+	}
+	mockStorage.addTaskFunc = func(task model.Task) (int64, error) {
+		if *task.ScheduleID == 1 {
+			return 0, errors.New("database is down")
+		}
+		return 100, nil
+	}
+	recorded := map[int64]string{}
+	mockStorage.scheduleError = func(id int64, message string) error {
+		recorded[id] = message
+		return nil
+	}
+
+	app.fireDueSchedules(now)
+
+	if message, ok := recorded[1]; !ok || message == "" {
+		t.Errorf("Expected the failed firing of schedule 1 to be recorded, got %q", message)
+	}
+	if message, ok := recorded[2]; !ok || message != "" {
+		t.Errorf("Expected the last error of schedule 2 to be cleared, got %q", message)
+	}
+}
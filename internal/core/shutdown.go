@@ -0,0 +1,115 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/model"
+	"context"
+	"errors"
+	"log"
+)
+
+var ErrShuttingDown = errors.New("service is shutting down")
+
+// errShutdown is the cause of the cancellation of requests aborted by
+// Shutdown.
+var errShutdown = errors.New("request aborted by shutdown")
+
+// Shutdown stops the service: new tasks are refused with ErrShuttingDown, the
+// scheduler, the schedules and the queue stop, and the workers finish their
+// current tasks. Requests still in flight when ctx is done are aborted and
+// their tasks are put back to new, to be sent again after a restart.
+func (a *App) Shutdown(ctx context.Context) error {
+	a.closeMu.Lock()
+	if a.closing {
+		a.closeMu.Unlock()
+		return nil
+	}
+	a.closing = true
+	a.closeMu.Unlock()
+
+	if a.scheduler != nil {
+		a.scheduler.Stop()
+	}
+	if a.stopped != nil {
+		close(a.stopped)
+	}
+	a.q.Close()
+
+	drained := make(chan struct{})
+	go func() {
+		a.workers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		log.Println("All workers finished")
+	case <-ctx.Done():
+		a.mu.Lock()
+		log.Printf("Shutdown deadline passed, aborting %d requests\n", len(a.inFlight))
+		for _, cancelRequest := range a.inFlight {
+			cancelRequest(errShutdown)
+		}
+		a.mu.Unlock()
+		<-drained
+	}
+
+	delivered := make(chan struct{})
+	go func() {
+		a.deliveries.Wait()
+		close(delivered)
+	}()
+	select {
+	case <-delivered:
+	case <-ctx.Done():
+		log.Println("Shutdown deadline passed before all webhooks were delivered")
+	}
+	// The event streams end last, so that they carry the final status of
+	// the drained tasks.
+	if a.events != nil {
+		a.events.Close()
+	}
+	return ctx.Err()
+}
+
+// beginWork registers a worker with Shutdown. It reports false once the
+// service is shutting down.
+func (a *App) beginWork() bool {
+	a.closeMu.RLock()
+	defer a.closeMu.RUnlock()
+	if a.closing {
+		return false
+	}
+	a.workers.Add(1)
+	return true
+}
+
+func (a *App) shuttingDown() bool {
+	a.closeMu.RLock()
+	defer a.closeMu.RUnlock()
+	return a.closing
+}
+
+// enqueue puts a task into the queue unless the service is shutting down; the
+// task then stays in storage as it is and is picked up after a restart. The
+// lock is not held while a full queue blocks, so that Shutdown can close the
+// queue, which drops the waiting task.
+func (a *App) enqueue(task model.Task) {
+	if a.shuttingDown() {
+		log.Printf("Task with ID %d not queued, the service is shutting down\n", task.ID)
+		return
+	}
+	a.q.Enqueque(task)
+}
+
+// releaseTask puts a task the worker will not finish back to new.
+func (a *App) releaseTask(task model.Task) {
+	task.NextRetryAt = nil
+	err := a.storage.UpdateTaskAttempts(&task)
+	if err != nil {
+		log.Printf("Error updating the attempts of task: %v\n", err)
+	}
+	err = a.setStatus(&task, model.New)
+	if err != nil {
+		log.Printf("Error updating the status of tasks to new: %v\n", err)
+	}
+	log.Printf("Task with ID %d put back to new for shutdown\n", task.ID)
+}
@@ -0,0 +1,170 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/client"
+	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/queue"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShutdownRefusesNewTasks(t *testing.T) {
+	mockQueue := &MockTaskQueue{}
+	closed := false
+	mockQueue.closeFunc = func() {
+		closed = true
+	}
+	app := NewApp(&MockStorage{}, WithQueue(mockQueue))
+	app.Initworkers(1)
+
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !closed {
+		t.Error("Expected the queue to be closed")
+	}
+
+	if _, err := app.CreateTask(model.Task{Method: "GET", URL: "https://example.com"}); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("Expected ErrShuttingDown, got %v", err)
+	}
+	if _, err := app.CreateTasks([]model.Task{{Method: "GET", URL: "https://example.com"}}); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("Expected ErrShuttingDown for a batch, got %v", err)
+	}
+}
+
+func TestShutdownWaitsForWorkers(t *testing.T) {
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	app := NewApp(mockStorage, WithQueue(mockQueue), WithClientFactory(func(options *model.RequestOptions) client.Client {
+		return &MockClient{sendFunc: func(ctx context.Context, task *model.Task) (*model.ResponseData, error) {
+			close(started)
+			<-release
+			return &model.ResponseData{StatusCode: 200}, nil
+		}}
+	}))
+	var mu sync.Mutex
+	var finalStatus string
+	mockStorage.updateFunc = func(task *model.Task, status string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		finalStatus = status
+		return nil
+	}
+	app.Initworkers(1)
+
+	go mockQueue.processFunc(model.Task{ID: 1, Method: "GET", URL: "https://example.com"})
+	<-started
+
+	shutdownDone := make(chan error)
+	go func() {
+		shutdownDone <- app.Shutdown(context.Background())
+	}()
+	select {
+	case <-shutdownDone:
+		t.Fatal("Shutdown returned while a task was in flight")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-shutdownDone; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if finalStatus != model.Done {
+		t.Errorf("Expected status %s, got %s", model.Done, finalStatus)
+	}
+}
+
+func TestShutdownRequeuesInFlightTasks(t *testing.T) {
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	started := make(chan struct{})
+	app := NewApp(mockStorage, WithQueue(mockQueue), WithClientFactory(func(options *model.RequestOptions) client.Client {
+		return &MockClient{sendFunc: func(ctx context.Context, task *model.Task) (*model.ResponseData, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}}
+	}))
+	var mu sync.Mutex
+	var finalStatus string
+	mockStorage.updateFunc = func(task *model.Task, status string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		finalStatus = status
+		return nil
+	}
+	app.Initworkers(1)
+
+	go mockQueue.processFunc(model.Task{ID: 1, Method: "GET", URL: "https://example.com"})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := app.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+	mu.Lock()
+	status := finalStatus
+	finalStatus = ""
+	mu.Unlock()
+	if status != model.New {
+		t.Errorf("Expected status %s, got %s", model.New, status)
+	}
+
+	// A task handed out by the queue after the shutdown is put back as well.
+	mockQueue.processFunc(model.Task{ID: 2, Status: model.In_process})
+	mu.Lock()
+	defer mu.Unlock()
+	if finalStatus != model.New {
+		t.Errorf("Expected status %s for a task claimed after shutdown, got %s", model.New, finalStatus)
+	}
+}
+
+func TestShutdownWithFullQueue(t *testing.T) {
+	queues := map[string]queue.TaskQueue{
+		"channel":  queue.NewTasksQueue(1),
+		"priority": queue.NewPriorityQueue(1, time.Minute),
+	}
+	for name, q := range queues {
+		t.Run(name, func(t *testing.T) {
+			app := NewApp(&MockStorage{}, WithQueue(q))
+			app.enqueue(model.Task{ID: 1})
+
+			enqueued := make(chan struct{})
+			go func() {
+				app.enqueue(model.Task{ID: 2})
+				close(enqueued)
+			}()
+			select {
+			case <-enqueued:
+				t.Fatal("Expected the enqueue to block while the queue is full")
+			case <-time.After(20 * time.Millisecond):
+			}
+
+			shutdownDone := make(chan error)
+			go func() {
+				shutdownDone <- app.Shutdown(context.Background())
+			}()
+			select {
+			case err := <-shutdownDone:
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Shutdown blocked on the full queue")
+			}
+			select {
+			case <-enqueued:
+			case <-time.After(time.Second):
+				t.Fatal("Blocked enqueue was not woken by the shutdown")
+			}
+		})
+	}
+}
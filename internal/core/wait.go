@@ -0,0 +1,81 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/model"
+	"context"
+	"time"
+)
+
+// WaitForTask returns the task once it is done, failed or cancelled, or in
+// its current state when the wait, the context or the service ends first.
+// The request is woken by the worker that finishes the task, so a task
+// finished by another instance is only seen when the wait ends.
+func (a *App) WaitForTask(ctx context.Context, tenant string, id int64, wait time.Duration) (model.Task, error) {
+	// The waiter is registered before the task is read, so that a task
+	// finishing in between is not missed.
+	finished, stop := a.awaitFinished(id)
+	defer stop()
+
+	task, err := a.storage.GetTaskByID(tenant, id)
+	if err != nil || isFinished(task.Status) || wait <= 0 {
+		return task, err
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-finished:
+	case <-timer.C:
+	case <-ctx.Done():
+	case <-a.stopped:
+	}
+	return a.storage.GetTaskByID(tenant, id)
+}
+
+func isFinished(status string) bool {
+	switch status {
+	case model.Done, model.Error, model.Cancelled:
+		return true
+	}
+	return false
+}
+
+// awaitFinished registers a waiter for the task. The returned channel is
+// closed when the task finishes; stop unregisters the waiter.
+func (a *App) awaitFinished(id int64) (<-chan struct{}, func()) {
+	finished := make(chan struct{})
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.waiting == nil {
+		a.waiting = make(map[int64][]chan struct{})
+	}
+	a.waiting[id] = append(a.waiting[id], finished)
+
+	stop := func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		waiters := a.waiting[id]
+		for i, waiter := range waiters {
+			if waiter == finished {
+				waiters = append(waiters[:i], waiters[i+1:]...)
+				break
+			}
+		}
+		if len(waiters) == 0 {
+			delete(a.waiting, id)
+		} else {
+			a.waiting[id] = waiters
+		}
+	}
+	return finished, stop
+}
+
+// wakeWaiters releases the requests waiting for the task to finish.
+func (a *App) wakeWaiters(id int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, finished := range a.waiting[id] {
+		close(finished)
+	}
+	delete(a.waiting, id)
+}
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/client"
+	"MyFirstGoApp/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWaitForTask(t *testing.T) {
+	t.Run("Finished task", func(t *testing.T) {
+		app := NewApp(&MockStorage{tasks: []model.Task{{ID: 1, Tenant: "team-a", Status: model.Done}}}, WithQueue(&MockTaskQueue{}))
+		start := time.Now()
+		task, err := app.WaitForTask(context.Background(), "team-a", 1, time.Minute)
+		if err != nil || task.Status != model.Done {
+			t.Fatalf("Expected the done task, got %+v, %v", task, err)
+		}
+		if time.Since(start) > time.Second {
+			t.Error("Expected a finished task to be returned right away")
+		}
+	})
+
+	t.Run("Task not found", func(t *testing.T) {
+		mockStorage := &MockStorage{}
+		mockStorage.getByIDFunc = func(id int64) (model.Task, error) {
+			return model.Task{}, sql.ErrNoRows
+		}
+		app := NewApp(mockStorage, WithQueue(&MockTaskQueue{}))
+		if _, err := app.WaitForTask(context.Background(), "team-a", 1, time.Minute); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expected sql.ErrNoRows, got %v", err)
+		}
+	})
+
+	t.Run("Wait expires", func(t *testing.T) {
+		app := NewApp(&MockStorage{tasks: []model.Task{{ID: 1, Tenant: "team-a", Status: model.In_process}}}, WithQueue(&MockTaskQueue{}))
+		task, err := app.WaitForTask(context.Background(), "team-a", 1, 20*time.Millisecond)
+		if err != nil || task.Status != model.In_process {
+			t.Errorf("Expected the task in process, got %+v, %v", task, err)
+		}
+		if len(app.waiting) != 0 {
+			t.Errorf("Expected the waiter to be removed, got %v", app.waiting)
+		}
+	})
+
+	t.Run("Woken by the worker", func(t *testing.T) {
+		mockStorage := &MockStorage{}
+		mockQueue := &MockTaskQueue{}
+		release := make(chan struct{})
+		app := NewApp(mockStorage, WithQueue(mockQueue), WithClientFactory(func(options *model.RequestOptions) client.Client {
+			return &MockClient{sendFunc: func(ctx context.Context, task *model.Task) (*model.ResponseData, error) {
+				<-release
+				return &model.ResponseData{StatusCode: 200}, nil
+			}}
+		}))
+		var mu sync.Mutex
+		status := model.New
+		mockStorage.getByIDFunc = func(id int64) (model.Task, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return model.Task{ID: id, Status: status}, nil
+		}
+		mockStorage.updateFunc = func(task *model.Task, s string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			status = s
+			return nil
+		}
+		app.Initworkers(1)
+		go mockQueue.processFunc(model.Task{ID: 1, Method: "GET", URL: "https://example.com"})
+
+		result := make(chan model.Task)
+		go func() {
+			task, _ := app.WaitForTask(context.Background(), "team-a", 1, time.Minute)
+			result <- task
+		}()
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+
+		select {
+		case task := <-result:
+			if task.Status != model.Done {
+				t.Errorf("Expected the done task, got status %q", task.Status)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Waiting request was not woken when the task finished")
+		}
+	})
+
+	t.Run("Shutdown", func(t *testing.T) {
+		app := NewApp(&MockStorage{tasks: []model.Task{{ID: 1, Tenant: "team-a", Status: model.New}}}, WithQueue(&MockTaskQueue{}))
+		result := make(chan model.Task)
+		go func() {
+			task, _ := app.WaitForTask(context.Background(), "team-a", 1, time.Minute)
+			result <- task
+		}()
+		time.Sleep(10 * time.Millisecond)
+		app.Shutdown(context.Background())
+
+		select {
+		case task := <-result:
+			if task.Status != model.New {
+				t.Errorf("Expected the task in its current state, got status %q", task.Status)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Waiting request did not end on shutdown")
+		}
+	})
+}
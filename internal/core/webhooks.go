@@ -0,0 +1,57 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/model"
+	"context"
+	"log"
+)
+
+// WithNotifier replaces the notifier that sends the completion webhooks.
+func WithNotifier(notifier Notifier) Option {
+	return func(a *App) {
+		a.webhooks = notifier
+	}
+}
+
+// Notifier delivers the webhook of a finished task.
+type Notifier interface {
+	Deliver(ctx context.Context, task model.Task) bool
+}
+
+// notifyFinished wakes the requests waiting for a task that reached done,
+// error or cancelled and sends its final state to its callback URL. The
+// delivery runs in the background with its own retries.
+func (a *App) notifyFinished(task model.Task) {
+	a.wakeWaiters(task.ID)
+	if task.CallbackURL == "" || a.webhooks == nil {
+		return
+	}
+	a.deliveries.Add(1)
+	go func() {
+		defer a.deliveries.Done()
+		// The stored task carries the response saved by the worker.
+		final, err := a.storage.GetTaskByID(task.Tenant, task.ID)
+		if err != nil {
+			log.Printf("Error loading task with ID %d for its webhook: %v\n", task.ID, err)
+			final = task
+		}
+		if !a.webhooks.Deliver(context.Background(), final) {
+			log.Printf("Webhook of task with ID %d was not delivered to %s\n", task.ID, task.CallbackURL)
+		}
+	}()
+}
+
+func (a *App) recordDelivery(delivery model.WebhookDelivery) {
+	err := a.storage.AddWebhookDelivery(delivery)
+	if err != nil {
+		log.Printf("Error saving webhook delivery of task with ID %d: %v\n", delivery.TaskID, err)
+	}
+}
+
+// GetWebhookDeliveries returns the delivery log of the webhooks of a task.
+func (a *App) GetWebhookDeliveries(tenant string, id int64) ([]model.WebhookDelivery, error) {
+	if _, err := a.storage.GetTaskByID(tenant, id); err != nil {
+		return nil, err
+	}
+	return a.storage.GetWebhookDeliveries(tenant, id)
+}
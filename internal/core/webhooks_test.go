@@ -0,0 +1,62 @@
+package core
+
+import (
+	"MyFirstGoApp/internal/client"
+	"MyFirstGoApp/internal/model"
+	"context"
+	"testing"
+	"time"
+)
+
+type MockNotifier struct {
+	delivered chan model.Task
+}
+
+func (m *MockNotifier) Deliver(ctx context.Context, task model.Task) bool {
+	m.delivered <- task
+	return true
+}
+
+func TestNotifyFinishedTask(t *testing.T) {
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	notifier := &MockNotifier{delivered: make(chan model.Task, 1)}
+	app := NewApp(mockStorage, WithQueue(mockQueue), WithNotifier(notifier),
+		WithClientFactory(func(options *model.RequestOptions) client.Client {
+			return &MockClient{}
+		}))
+	mockStorage.getByIDFunc = func(id int64) (model.Task, error) {
+		return model.Task{ID: id, Status: model.Done, CallbackURL: "https://example.com/hook"}, nil
+	}
+	app.Initworkers(1)
+
+	mockQueue.processFunc(model.Task{ID: 4, Method: "GET", URL: "https://example.com", CallbackURL: "https://example.com/hook"})
+
+	select {
+	case task := <-notifier.delivered:
+		if task.ID != 4 || task.Status != model.Done {
+			t.Errorf("Unexpected webhook payload %+v", task)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Webhook was not sent")
+	}
+}
+
+func TestNotifyFinishedWithoutCallback(t *testing.T) {
+	mockStorage := &MockStorage{}
+	mockQueue := &MockTaskQueue{}
+	notifier := &MockNotifier{delivered: make(chan model.Task, 1)}
+	app := NewApp(mockStorage, WithQueue(mockQueue), WithNotifier(notifier),
+		WithClientFactory(func(options *model.RequestOptions) client.Client {
+			return &MockClient{}
+		}))
+	app.Initworkers(1)
+
+	mockQueue.processFunc(model.Task{ID: 4, Method: "GET", URL: "https://example.com"})
+
+	select {
+	case task := <-notifier.delivered:
+		t.Errorf("Expected no webhook, got %+v", task)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
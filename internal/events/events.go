@@ -0,0 +1,221 @@
+package events
+
+import (
+	"MyFirstGoApp/internal/model"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Types of events.
+const (
+	StatusChanged  = "status"
+	ResponseStored = "response"
+	// Resync tells a subscriber that resumed from an event of another
+	// broker, such as one before a restart, that it missed an unknown number
+	// of events and has to reload the tasks it shows.
+	Resync = "resync"
+)
+
+// DefaultHistorySize is the number of recent events a broker keeps for
+// subscribers that resume after a reconnect.
+const DefaultHistorySize = 1000
+
+// subscriberBuffer is the number of events a subscriber may fall behind
+// before it is dropped.
+const subscriberBuffer = 64
+
+// Event is a change of a task. Event IDs grow by one with every event of a
+// broker and start over with every broker, which the epoch tells apart.
+type Event struct {
+	ID    int64
+	Epoch string
+	Type  string
+	Task  model.Task
+}
+
+// StreamID is the ID of the event on an event stream, <epoch>-<id>.
+func (e Event) StreamID() string {
+	return e.Epoch + "-" + strconv.FormatInt(e.ID, 10)
+}
+
+// ParseStreamID splits the stream ID of an event into its epoch and ID.
+func ParseStreamID(value string) (epoch string, id int64, err error) {
+	epoch, idStr, ok := strings.Cut(value, "-")
+	if ok {
+		id, err = strconv.ParseInt(idStr, 10, 64)
+	}
+	if !ok || epoch == "" || err != nil || id < 0 {
+		return "", 0, fmt.Errorf("event ID %q is not of the form <epoch>-<id>", value)
+	}
+	return epoch, id, nil
+}
+
+// Filter selects the events of a subscription. An empty field matches every
+// task; otherwise a task must belong to the tenant and have one of the IDs,
+// one of the statuses and all of the tags.
+type Filter struct {
+	Tenant   string
+	IDs      []int64
+	Statuses []string
+	Tags     []string
+}
+
+func (f Filter) Match(task model.Task) bool {
+	if f.Tenant != "" && task.Tenant != f.Tenant {
+		return false
+	}
+	if len(f.IDs) > 0 && !slices.Contains(f.IDs, task.ID) {
+		return false
+	}
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, task.Status) {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(task.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// Broker fans the events of task changes out to subscribers. It keeps the
+// last events in a ring buffer, so that a subscriber that reconnects with
+// the ID of the last event it saw gets the events it missed.
+type Broker struct {
+	mu          sync.Mutex
+	epoch       string
+	lastID      int64
+	history     []Event
+	next        int
+	full        bool
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+func NewBroker(historySize int) *Broker {
+	if historySize < 1 {
+		historySize = DefaultHistorySize
+	}
+	return &Broker{
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		history:     make([]Event, historySize),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish sends an event about the task to every matching subscriber. A
+// subscriber too slow to keep up is dropped instead of holding up the
+// worker; it can resume from its last event.
+func (b *Broker) Publish(eventType string, task model.Task) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	b.lastID++
+	event := Event{ID: b.lastID, Epoch: b.epoch, Type: eventType, Task: task}
+	b.history[b.next] = event
+	b.next = (b.next + 1) % len(b.history)
+	if b.next == 0 {
+		b.full = true
+	}
+
+	for sub := range b.subscribers {
+		if !sub.filter.Match(task) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe starts a subscription to the events matching the filter. With
+// the stream ID of the last event a subscriber saw, the buffered events after
+// it are delivered first. An ID of another broker, such as one before a
+// restart, gets a Resync event instead, as the events in between are lost.
+func (b *Broker) Subscribe(filter Filter, lastEventID string) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var missed []Event
+	if lastEventID != "" {
+		epoch, id, err := ParseStreamID(lastEventID)
+		if err != nil || epoch != b.epoch || id > b.lastID {
+			missed = append(missed, Event{ID: b.lastID, Epoch: b.epoch, Type: Resync})
+		} else {
+			for _, event := range b.buffered() {
+				if event.ID > id && filter.Match(event.Task) {
+					missed = append(missed, event)
+				}
+			}
+		}
+	}
+
+	sub := &Subscription{
+		broker: b,
+		filter: filter,
+		events: make(chan Event, len(missed)+subscriberBuffer),
+	}
+	for _, event := range missed {
+		sub.events <- event
+	}
+	if b.closed {
+		close(sub.events)
+		return sub
+	}
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// buffered returns the events in the ring buffer, oldest first.
+func (b *Broker) buffered() []Event {
+	if !b.full {
+		return slices.Clone(b.history[:b.next])
+	}
+	return append(slices.Clone(b.history[b.next:]), b.history[:b.next]...)
+}
+
+// Close ends all subscriptions. Events published afterwards are dropped.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+}
+
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Subscription receives the events matching its filter until it is closed,
+// dropped for falling behind or its broker is closed.
+type Subscription struct {
+	broker *Broker
+	filter Filter
+	events chan Event
+}
+
+// Events returns the channel of events, which is closed when the
+// subscription ends.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
@@ -0,0 +1,168 @@
+package events
+
+import (
+	"MyFirstGoApp/internal/model"
+	"strconv"
+	"testing"
+)
+
+func receive(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+	select {
+	case event, ok := <-sub.Events():
+		if !ok {
+			t.Fatal("Subscription ended unexpectedly")
+		}
+		return event
+	default:
+		t.Fatal("Expected an event")
+		return Event{}
+	}
+}
+
+func expectNone(t *testing.T, sub *Subscription) {
+	t.Helper()
+	select {
+	case event, ok := <-sub.Events():
+		if ok {
+			t.Fatalf("Expected no event, got %+v", event)
+		}
+	default:
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	task := model.Task{ID: 1, Tenant: "team-a", Status: model.Done, Tags: []string{"billing", "nightly"}}
+	tests := []struct {
+		filter Filter
+		match  bool
+	}{
+		{Filter{}, true},
+		{Filter{IDs: []int64{2, 1}}, true},
+		{Filter{IDs: []int64{2}}, false},
+		{Filter{Statuses: []string{model.Error, model.Done}}, true},
+		{Filter{Statuses: []string{model.Error}}, false},
+		{Filter{Tags: []string{"billing", "nightly"}}, true},
+		{Filter{Tags: []string{"billing", "hourly"}}, false},
+		{Filter{IDs: []int64{1}, Statuses: []string{model.New}}, false},
+		{Filter{Tenant: "team-a", IDs: []int64{1}}, true},
+		{Filter{Tenant: "team-b"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Match(task); got != tt.match {
+			t.Errorf("Filter %+v: expected match %v, got %v", tt.filter, tt.match, got)
+		}
+	}
+}
+
+func TestPublishToMatchingSubscribers(t *testing.T) {
+	b := NewBroker(10)
+	all := b.Subscribe(Filter{}, "")
+	done := b.Subscribe(Filter{Statuses: []string{model.Done}}, "")
+
+	b.Publish(StatusChanged, model.Task{ID: 1, Status: model.In_process})
+	b.Publish(StatusChanged, model.Task{ID: 1, Status: model.Done})
+
+	if event := receive(t, all); event.ID != 1 || event.Task.Status != model.In_process {
+		t.Errorf("Unexpected first event %+v", event)
+	}
+	if event := receive(t, all); event.ID != 2 || event.Type != StatusChanged {
+		t.Errorf("Unexpected second event %+v", event)
+	}
+	if event := receive(t, done); event.ID != 2 {
+		t.Errorf("Expected only the done event, got %+v", event)
+	}
+	expectNone(t, done)
+
+	all.Close()
+	b.Publish(StatusChanged, model.Task{ID: 2, Status: model.New})
+	if _, ok := <-all.Events(); ok {
+		t.Error("Expected the closed subscription to get no events")
+	}
+}
+
+func TestSubscribeResumes(t *testing.T) {
+	b := NewBroker(3)
+	for i := 1; i <= 5; i++ {
+		b.Publish(StatusChanged, model.Task{ID: int64(i), Status: model.New})
+	}
+
+	// Events 1 and 2 have left the buffer of three.
+	sub := b.Subscribe(Filter{}, b.epoch+"-1")
+	for _, id := range []int64{3, 4, 5} {
+		if event := receive(t, sub); event.ID != id || event.StreamID() != b.epoch+"-"+strconv.FormatInt(id, 10) {
+			t.Errorf("Expected event %d, got %s", id, event.StreamID())
+		}
+	}
+	expectNone(t, sub)
+
+	sub = b.Subscribe(Filter{IDs: []int64{5}}, b.epoch+"-3")
+	if event := receive(t, sub); event.ID != 5 {
+		t.Errorf("Expected event 5, got %d", event.ID)
+	}
+	expectNone(t, sub)
+}
+
+func TestSubscribeResyncs(t *testing.T) {
+	// IDs from before a restart, without an epoch or beyond the last event
+	// cannot be resumed from.
+	for _, lastEventID := range []string{"otherepoch-1", "1", "garbage", ""} {
+		b := NewBroker(3)
+		b.Publish(StatusChanged, model.Task{ID: 1, Status: model.New})
+		b.Publish(StatusChanged, model.Task{ID: 2, Status: model.New})
+		if lastEventID == "" {
+			lastEventID = b.epoch + "-100"
+		}
+
+		sub := b.Subscribe(Filter{IDs: []int64{1}}, lastEventID)
+		event := receive(t, sub)
+		if event.Type != Resync || event.StreamID() != b.epoch+"-2" {
+			t.Errorf("Last event %q: expected a resync at %s-2, got %+v", lastEventID, b.epoch, event)
+		}
+		expectNone(t, sub)
+
+		b.Publish(StatusChanged, model.Task{ID: 1, Status: model.Done})
+		if event := receive(t, sub); event.Type != StatusChanged || event.ID != 3 {
+			t.Errorf("Expected the live events after the resync, got %+v", event)
+		}
+	}
+}
+
+func TestParseStreamID(t *testing.T) {
+	epoch, id, err := ParseStreamID(Event{ID: 42, Epoch: "abc"}.StreamID())
+	if err != nil || epoch != "abc" || id != 42 {
+		t.Errorf("Expected abc and 42, got %q, %d, %v", epoch, id, err)
+	}
+	for _, value := range []string{"", "42", "-42", "abc-", "abc-x", "abc--1"} {
+		if _, _, err := ParseStreamID(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	b := NewBroker(10)
+	sub := b.Subscribe(Filter{}, "")
+	for i := 0; i <= subscriberBuffer; i++ {
+		b.Publish(StatusChanged, model.Task{ID: 1, Status: model.New})
+	}
+
+	received := 0
+	for range sub.Events() {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("Expected %d buffered events before the drop, got %d", subscriberBuffer, received)
+	}
+}
+
+func TestCloseEndsSubscriptions(t *testing.T) {
+	b := NewBroker(10)
+	sub := b.Subscribe(Filter{}, "")
+	b.Close()
+	if _, ok := <-sub.Events(); ok {
+		t.Error("Expected the subscription to end")
+	}
+	b.Publish(StatusChanged, model.Task{ID: 1})
+	sub.Close()
+}
@@ -1,12 +1,85 @@
 package model
 
-import "net/http"
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
 
 const (
 	Error      = "error"
 	In_process = "in_process"
 	Done       = "done"
 	New        = "new"
+	Retrying   = "retrying"
+	Scheduled  = "scheduled"
+	Cancelled  = "cancelled"
+)
+
+// UnfinishedStatuses are the statuses of the tasks still waiting to be sent or
+// being sent.
+var UnfinishedStatuses = []string{New, Scheduled, Retrying, In_process}
+
+// Network error kinds a retry policy can treat as retryable.
+const (
+	ErrTimeout           = "timeout"
+	ErrConnectionRefused = "connection_refused"
+	ErrConnectionReset   = "connection_reset"
+	ErrDNS               = "dns"
+	ErrTLS               = "tls"
+)
+
+var NetworkErrors = []string{ErrTimeout, ErrConnectionRefused, ErrConnectionReset, ErrDNS, ErrTLS}
+
+const (
+	RedirectFollow = "follow"
+	RedirectNone   = "none"
+)
+
+const (
+	TLSVerify = "verify"
+	TLSSkip   = "skip"
+)
+
+// Scopes of API keys. An admin key may also read and write the tasks of its
+// tenant; a deployment admin key may do everything, for every tenant.
+const (
+	ScopeTasksRead       = "tasks:read"
+	ScopeTasksWrite      = "tasks:write"
+	ScopeTasksAdmin      = "tasks:admin"
+	ScopeDeploymentAdmin = "deployment:admin"
+)
+
+var Scopes = []string{ScopeTasksRead, ScopeTasksWrite, ScopeTasksAdmin, ScopeDeploymentAdmin}
+
+// DefaultTenant owns the data created before tenants were introduced and the
+// bootstrap admin key.
+const DefaultTenant = "default"
+
+const MaxTenantLength = 64
+
+const (
+	MaxTags      = 20
+	MaxTagLength = 64
+)
+
+// Priorities of tasks range from MinPriority to MaxPriority.
+const (
+	MinPriority = -1000
+	MaxPriority = 1000
+)
+
+const (
+	BodyText   = "text"
+	BodyJSON   = "json"
+	BodyBase64 = "base64"
 )
 
 type Task struct {
@@ -16,18 +89,671 @@ type Task struct {
 	URL string `json:"url"`
 	// @Description HTTP headers
 	Headers map[string]string `json:"headers"`
+	// @Description Request body
+	Body *Body `json:"body,omitempty"`
 	// @Description Task ID
 	ID int64 `json:"id"`
 	// @Description Task status
 	Status string `json:"status"`
+	// @Description Time the task was created
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	// @Description Time the task last entered the queue
+	QueuedAt *time.Time `json:"queued_at,omitempty"`
+	// @Description Time the first attempt of the task started
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	// @Description Time the task was done, failed or cancelled
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// @Description Time between started_at and finished_at
+	Duration Duration `json:"duration,omitempty" swaggertype:"string" example:"1.5s"`
 	// @Description HTTP response
 	Response ResponseData `json:"response"`
+	// @Description Retry policy, the task is sent once when omitted
+	Retry *RetryPolicy `json:"retry,omitempty"`
+	// @Description Number of attempts made so far
+	Attempts int `json:"attempts"`
+	// @Description Time of the next retry
+	NextRetryAt *time.Time `json:"next_retry_at,omitempty"`
+	// @Description Time to send the task at, the task is sent right away when omitted
+	RunAt *time.Time `json:"run_at,omitempty"`
+	// @Description Delay before the task is sent, an alternative to run_at
+	Delay Duration `json:"delay,omitempty" swaggertype:"string" example:"10m"`
+	// @Description ID of the recurring schedule that created the task
+	ScheduleID *int64 `json:"schedule_id,omitempty"`
+	// @Description Subject of the API key (apikey:<id>) or token (jwt:<sub>) that created the task, set by the server
+	CreatedBy string `json:"created_by,omitempty" example:"apikey:2"`
+	// @Description Tenant the task belongs to, taken from the credentials of the request
+	Tenant string `json:"tenant,omitempty" example:"team-a"`
+	// @Description Options of the outbound request
+	Options *RequestOptions `json:"options,omitempty"`
+	// @Description Priority in the queue from -1000 to 1000, tasks with a higher priority are sent first
+	Priority int `json:"priority"`
+	// @Description Labels to filter the task list and the event stream by
+	Tags []string `json:"tags,omitempty" example:"billing"`
+	// @Description Idempotency-Key header of the request that created the task
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// Hash of the creating request, compared when the idempotency key is reused.
+	RequestHash string `json:"-"`
+	// @Description URL the final task is POSTed to when it is done, failed or cancelled
+	CallbackURL string `json:"callback_url,omitempty" example:"https://example.com/hooks/tasks"`
+	// @Description Checks of the response that decide between done and error, any response is done when omitted
+	Assertions *Assertions `json:"assertions,omitempty"`
+	// @Description Outcome of each assertion for the last response
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+	// @Description Instance that last worked on the task
+	LeaseOwner string `json:"lease_owner,omitempty"`
+	// @Description Time the task is taken back from its owner unless the owner renews the lease
+	LeaseExpiresAt *time.Time `json:"lease_expires_at,omitempty"`
+}
+
+type RequestOptions struct {
+	// @Description Total time limit of the request, 10s by default
+	Timeout Duration `json:"timeout,omitempty" swaggertype:"string" example:"30s"`
+	// @Description Time limit for establishing the connection
+	ConnectTimeout Duration `json:"connect_timeout,omitempty" swaggertype:"string" example:"5s"`
+	// @Description Redirect policy: follow (default) or none
+	Redirects string `json:"redirects,omitempty"`
+	// @Description Maximum number of redirects to follow, 10 by default
+	MaxRedirects int `json:"max_redirects,omitempty"`
+	// @Description TLS certificate verification: verify (default) or skip
+	TLS string `json:"tls,omitempty"`
+}
+
+type Schedule struct {
+	// @Description Schedule ID
+	ID int64 `json:"id"`
+	// @Description Schedule name
+	Name string `json:"name"`
+	// @Description Cron expression with five fields or a descriptor such as @hourly
+	Cron string `json:"cron" example:"*/5 * * * *"`
+	// @Description IANA time zone the cron expression is evaluated in, UTC by default
+	Timezone string `json:"timezone" example:"Europe/Moscow"`
+	// @Description Template of the task created on every firing
+	Task Task `json:"task"`
+	// @Description Whether the schedule fires
+	Enabled bool `json:"enabled"`
+	// @Description Time of the next firing
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+	// @Description Time of the last firing
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	// @Description Why the last firing created no task, empty when it did
+	LastError string `json:"last_error,omitempty"`
+	// @Description Tenant the schedule and its tasks belong to, taken from the credentials of the request
+	Tenant string `json:"tenant,omitempty" example:"team-a"`
+}
+
+type RetryPolicy struct {
+	// @Description Maximum number of attempts including the first one
+	MaxAttempts int `json:"max_attempts"`
+	// @Description Delay before the first retry, doubled on every next one
+	BaseDelay Duration `json:"base_delay" swaggertype:"string" example:"1s"`
+	// @Description Upper bound for the delay between retries
+	MaxDelay Duration `json:"max_delay" swaggertype:"string" example:"1m"`
+	// @Description Fraction of the delay that is randomized, from 0 to 1
+	Jitter float64 `json:"jitter"`
+	// @Description Response status codes that are retried, defaults to 429, 500, 502, 503 and 504
+	RetryOnStatus []int `json:"retry_on_status,omitempty"`
+	// @Description Network errors that are retried: timeout, connection_refused, connection_reset, dns, tls; defaults to all of them
+	RetryOnErrors []string `json:"retry_on_errors,omitempty"`
+}
+
+// Duration is a time.Duration encoded in JSON as a string such as "1.5s".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = Duration(parsed)
+	case float64:
+		*d = Duration(time.Duration(v))
+	case nil:
+		*d = 0
+	default:
+		return fmt.Errorf("invalid duration %s", string(data))
+	}
+	return nil
+}
+
+type Body struct {
+	// @Description Body type: text, json or base64
+	Type string `json:"type"`
+	// @Description Content-Type of the body, defaults depend on the body type
+	ContentType string `json:"content_type,omitempty"`
+	// @Description Body content: a string for text and base64, any JSON value for json
+	Content json.RawMessage `json:"content" swaggertype:"object"`
+}
+
+type BatchResult struct {
+	// @Description Position of the task in the batch
+	Index int `json:"index"`
+	// @Description ID of the created task
+	ID int64 `json:"id,omitempty"`
+	// @Description Validation error of the task
+	Error string `json:"error,omitempty"`
+}
+
+type Assertions struct {
+	// @Description Accepted status codes: exact codes such as 201, classes such as 2xx or ranges such as 200-299
+	Status []string `json:"status,omitempty" example:"2xx"`
+	// @Description Required response headers; an empty value only requires the header to be present
+	Headers map[string]string `json:"headers,omitempty"`
+	// @Description Regular expression the response body has to match
+	BodyRegex string `json:"body_regex,omitempty" example:"^OK$"`
+	// @Description Values the JSON response body has to contain
+	JSONPath []JSONPathAssertion `json:"json_path,omitempty"`
+}
+
+type JSONPathAssertion struct {
+	// @Description Path into the JSON body such as $.data.items[0].id
+	Path string `json:"path" example:"$.data.id"`
+	// @Description Expected value at the path
+	Equals json.RawMessage `json:"equals" swaggertype:"object"`
+}
+
+type AssertionResult struct {
+	// @Description The checked assertion, such as status, header Content-Type, body_regex or json_path $.data.id
+	Assertion string `json:"assertion"`
+	// @Description Whether the response passed the assertion
+	Passed bool `json:"passed"`
+	// @Description Why the assertion failed
+	Message string `json:"message,omitempty"`
+}
+
+type TaskAttempt struct {
+	// @Description Attempt ID
+	ID int64 `json:"id"`
+	// @Description ID of the task
+	TaskID int64 `json:"task_id"`
+	// @Description Number of the attempt, starting at 1
+	Attempt int `json:"attempt"`
+	// @Description Time the request was sent
+	StartedAt time.Time `json:"started_at"`
+	// @Description Time the response or error arrived
+	FinishedAt time.Time `json:"finished_at"`
+	// @Description Time between started_at and finished_at
+	Duration Duration `json:"duration" swaggertype:"string" example:"250ms"`
+	// @Description The request as it was sent
+	Request AttemptRequest `json:"request"`
+	// @Description The response, missing when the request failed
+	Response *ResponseData `json:"response,omitempty"`
+	// @Description Error of a request that got no response
+	Error string `json:"error,omitempty"`
+}
+
+type TaskTransition struct {
+	// @Description Transition ID
+	ID int64 `json:"id"`
+	// @Description ID of the task
+	TaskID int64 `json:"task_id"`
+	// @Description Status before the transition, missing when the task was created
+	From string `json:"from,omitempty"`
+	// @Description Status after the transition
+	To string `json:"to"`
+	// @Description Time of the transition
+	At time.Time `json:"at"`
+}
+
+type AttemptRequest struct {
+	// @Description HTTP method
+	Method string `json:"method"`
+	// @Description Target URL
+	URL string `json:"url"`
+	// @Description HTTP headers
+	Headers http.Header `json:"headers" swaggertype:"object"`
+	// @Description Request body
+	Body *Body `json:"body,omitempty"`
+}
+
+type RateLimit struct {
+	// @Description Host the limit applies to: an exact host, *.domain for its subdomains or * for every host
+	Pattern string `json:"pattern" example:"*.partner.com"`
+	// @Description Requests per second to each matching host, unlimited when 0
+	RequestsPerSecond float64 `json:"requests_per_second" example:"10"`
+	// @Description Requests that can be sent at once before the rate applies, defaults to the rate rounded up
+	Burst int `json:"burst,omitempty" example:"20"`
+	// @Description Requests in flight to each matching host at the same time, unlimited when 0
+	MaxConcurrent int `json:"max_concurrent,omitempty" example:"5"`
+}
+
+// TenantQuota limits the share of the service one tenant may use, so that a
+// busy tenant cannot starve the others.
+type TenantQuota struct {
+	// @Description Tenant the quota applies to, or * for the tenants without a quota of their own
+	Tenant string `json:"tenant" example:"team-a"`
+	// @Description Unfinished tasks the tenant may have; creating more is refused with 429, unlimited when 0
+	MaxQueued int `json:"max_queued" example:"10000"`
+	// @Description Fraction of the workers that may send the tenant's tasks at once, from 0 to 1, unlimited when 0
+	WorkerShare float64 `json:"worker_share" example:"0.5"`
+}
+
+type BreakerState struct {
+	// @Description Destination host
+	Host string `json:"host"`
+	// @Description Circuit state: closed, open or half_open
+	State string `json:"state"`
+	// @Description Consecutive failed requests
+	Failures int `json:"failures"`
+	// @Description Time the circuit was opened
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+	// @Description Time the next request is let through to probe the host
+	RetryAt *time.Time `json:"retry_at,omitempty"`
+}
+
+type WebhookDelivery struct {
+	// @Description Delivery ID
+	ID int64 `json:"id"`
+	// @Description ID of the task the webhook was sent for
+	TaskID int64 `json:"task_id"`
+	// @Description Callback URL the webhook was sent to
+	URL string `json:"url"`
+	// @Description Task status the webhook reported
+	Status string `json:"status"`
+	// @Description Number of the delivery attempt, starting at 1
+	Attempt int `json:"attempt"`
+	// @Description Response status code of the callback
+	StatusCode int `json:"status_code,omitempty"`
+	// @Description Error of a failed delivery
+	Error string `json:"error,omitempty"`
+	// @Description Whether the callback answered with a 2xx status
+	Success bool `json:"success"`
+	// @Description Time of the attempt
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type APIKey struct {
+	// @Description API key ID
+	ID int64 `json:"id"`
+	// @Description Name of the key, such as the service that uses it
+	Name string `json:"name" example:"billing-service"`
+	// @Description First characters of the key, to recognize it
+	Prefix string `json:"prefix"`
+	// @Description Permissions of the key: tasks:read, tasks:write, tasks:admin, deployment:admin
+	Scopes []string `json:"scopes" example:"tasks:read,tasks:write"`
+	// @Description Tenant whose tasks the key works with, the tenant of the issuing key by default
+	Tenant string `json:"tenant" example:"team-a"`
+	// @Description Time the key was issued
+	CreatedAt time.Time `json:"created_at"`
+	// @Description Time the key was revoked
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	// SHA-256 of the key; the key itself is never stored.
+	Hash string `json:"-"`
+}
+
+// IssuedAPIKey is a new API key together with its secret, which is returned
+// only once.
+type IssuedAPIKey struct {
+	APIKey
+	// @Description The key to send in the X-API-Key header, shown only once
+	Key string `json:"key"`
 }
 
 type ResponseData struct {
 	Status        string      `json:"status"`
 	StatusCode    int         `json:"status_code"`
-	Headers       http.Header `json:"headers"`
+	Headers       http.Header `json:"headers" swaggertype:"object"`
 	ContentLength int64       `json:"content_length"`
 	Body          string      `json:"body"`
 }
+
+func (t *Task) Validate() error {
+	if t.Body != nil {
+		if _, err := t.Body.Bytes(); err != nil {
+			return fmt.Errorf("invalid body: %w", err)
+		}
+	}
+	if t.Retry != nil {
+		if err := t.Retry.Validate(); err != nil {
+			return fmt.Errorf("invalid retry policy: %w", err)
+		}
+	}
+	if t.Options != nil {
+		if err := t.Options.Validate(); err != nil {
+			return fmt.Errorf("invalid options: %w", err)
+		}
+	}
+	if t.Delay < 0 {
+		return fmt.Errorf("delay must not be negative")
+	}
+	if t.Priority < MinPriority || t.Priority > MaxPriority {
+		return fmt.Errorf("priority must be between %d and %d", MinPriority, MaxPriority)
+	}
+	if t.Delay > 0 && t.RunAt != nil {
+		return fmt.Errorf("only one of run_at and delay can be set")
+	}
+	if t.CallbackURL != "" {
+		callback, err := url.Parse(t.CallbackURL)
+		if err != nil || (callback.Scheme != "http" && callback.Scheme != "https") || callback.Host == "" {
+			return fmt.Errorf("callback_url must be an absolute http or https URL")
+		}
+	}
+	if t.Assertions != nil {
+		if err := t.Assertions.Validate(); err != nil {
+			return fmt.Errorf("invalid assertions: %w", err)
+		}
+	}
+	if len(t.Tags) > MaxTags {
+		return fmt.Errorf("a task can have at most %d tags", MaxTags)
+	}
+	for _, tag := range t.Tags {
+		if tag == "" || len(tag) > MaxTagLength {
+			return fmt.Errorf("tags must be 1 to %d characters long", MaxTagLength)
+		}
+	}
+	return nil
+}
+
+func (a *Assertions) Validate() error {
+	for _, pattern := range a.Status {
+		if _, _, err := ParseStatusRange(pattern); err != nil {
+			return err
+		}
+	}
+	if a.BodyRegex != "" {
+		if _, err := regexp.Compile(a.BodyRegex); err != nil {
+			return fmt.Errorf("invalid body_regex: %w", err)
+		}
+	}
+	for _, check := range a.JSONPath {
+		if _, err := ParseJSONPath(check.Path); err != nil {
+			return fmt.Errorf("invalid json_path: %w", err)
+		}
+		if !json.Valid(check.Equals) {
+			return fmt.Errorf("json_path %q needs a JSON value to compare with", check.Path)
+		}
+	}
+	return nil
+}
+
+// ParseStatusRange parses a status assertion such as 201, 2xx or 200-299 into
+// the inclusive range of codes it accepts.
+func ParseStatusRange(pattern string) (from int, to int, err error) {
+	invalid := fmt.Errorf("invalid status %q, expected a code such as 201, a class such as 2xx or a range such as 200-299", pattern)
+	if len(pattern) == 3 && strings.HasSuffix(pattern, "xx") && pattern[0] >= '1' && pattern[0] <= '5' {
+		from = int(pattern[0]-'0') * 100
+		return from, from + 99, nil
+	}
+	first, last, isRange := strings.Cut(pattern, "-")
+	from, err = strconv.Atoi(first)
+	if err != nil {
+		return 0, 0, invalid
+	}
+	to = from
+	if isRange {
+		to, err = strconv.Atoi(last)
+		if err != nil {
+			return 0, 0, invalid
+		}
+	}
+	if from < 100 || to > 599 || from > to {
+		return 0, 0, invalid
+	}
+	return from, to, nil
+}
+
+// ParseJSONPath splits a JSONPath of the form $.key[0]['other key'] into object
+// keys (strings) and array indexes (ints). Wildcards, slices and filters are
+// not supported.
+func ParseJSONPath(path string) ([]any, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("path %q must start with $", path)
+	}
+	var steps []any
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[") + 1
+			if end == 0 {
+				end = len(rest)
+			}
+			key := rest[1:end]
+			if key == "" {
+				return nil, fmt.Errorf("empty key in path %q", path)
+			}
+			steps = append(steps, key)
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in path %q", path)
+			}
+			inner := rest[1:end]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, inner[1:len(inner)-1])
+			} else {
+				index, err := strconv.Atoi(inner)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("invalid index %q in path %q", inner, path)
+				}
+				steps = append(steps, index)
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q in path %q", rest[0], path)
+		}
+	}
+	return steps, nil
+}
+
+func (p *RetryPolicy) Validate() error {
+	if p.MaxAttempts < 1 {
+		return fmt.Errorf("max_attempts must be at least 1")
+	}
+	if p.BaseDelay < 0 || p.MaxDelay < 0 {
+		return fmt.Errorf("delays must not be negative")
+	}
+	if p.MaxDelay > 0 && p.MaxDelay < p.BaseDelay {
+		return fmt.Errorf("max_delay must not be less than base_delay")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1")
+	}
+	for _, kind := range p.RetryOnErrors {
+		known := false
+		for _, networkError := range NetworkErrors {
+			if kind == networkError {
+				known = true
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown network error %q", kind)
+		}
+	}
+	return nil
+}
+
+func (r *RateLimit) Validate() error {
+	pattern := strings.TrimPrefix(r.Pattern, "*.")
+	if r.Pattern != "*" && (pattern == "" || strings.ContainsAny(pattern, "*/: ")) {
+		return fmt.Errorf("pattern must be a host, *.domain or *")
+	}
+	if r.RequestsPerSecond < 0 || r.Burst < 0 || r.MaxConcurrent < 0 {
+		return fmt.Errorf("limits must not be negative")
+	}
+	if r.RequestsPerSecond == 0 && r.MaxConcurrent == 0 {
+		return fmt.Errorf("one of requests_per_second and max_concurrent must be set")
+	}
+	return nil
+}
+
+func (q *TenantQuota) Validate() error {
+	if q.Tenant != "*" {
+		if err := ValidateTenant(q.Tenant); err != nil {
+			return err
+		}
+	}
+	if q.MaxQueued < 0 {
+		return fmt.Errorf("max_queued must not be negative")
+	}
+	if q.WorkerShare < 0 || q.WorkerShare > 1 {
+		return fmt.Errorf("worker_share must be between 0 and 1")
+	}
+	return nil
+}
+
+// ValidateTenant checks a tenant name: letters, digits, '.', '-' and '_'.
+func ValidateTenant(tenant string) error {
+	if tenant == "" || len(tenant) > MaxTenantLength {
+		return fmt.Errorf("tenant must be 1 to %d characters long", MaxTenantLength)
+	}
+	for _, r := range tenant {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_') {
+			return fmt.Errorf("tenant %q may only contain letters, digits, '.', '-' and '_'", tenant)
+		}
+	}
+	return nil
+}
+
+func (k *APIKey) Validate() error {
+	if strings.TrimSpace(k.Name) == "" {
+		return fmt.Errorf("name must be set")
+	}
+	if err := ValidateTenant(k.Tenant); err != nil {
+		return err
+	}
+	if len(k.Scopes) == 0 {
+		return fmt.Errorf("at least one scope must be set")
+	}
+	for _, scope := range k.Scopes {
+		if !slices.Contains(Scopes, scope) {
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	return nil
+}
+
+func (o *RequestOptions) Validate() error {
+	if o.Timeout < 0 || o.ConnectTimeout < 0 {
+		return fmt.Errorf("timeouts must not be negative")
+	}
+	if o.MaxRedirects < 0 {
+		return fmt.Errorf("max_redirects must not be negative")
+	}
+	switch o.Redirects {
+	case "", RedirectFollow, RedirectNone:
+	default:
+		return fmt.Errorf("unknown redirect policy %q", o.Redirects)
+	}
+	switch o.TLS {
+	case "", TLSVerify, TLSSkip:
+	default:
+		return fmt.Errorf("unknown TLS mode %q", o.TLS)
+	}
+	return nil
+}
+
+// Bytes returns the raw payload that is sent to the third-party service.
+func (b *Body) Bytes() ([]byte, error) {
+	if b == nil || len(b.Content) == 0 {
+		return nil, nil
+	}
+	switch b.Type {
+	case BodyText:
+		var text string
+		if err := json.Unmarshal(b.Content, &text); err != nil {
+			return nil, fmt.Errorf("text content must be a string: %w", err)
+		}
+		return []byte(text), nil
+	case BodyJSON:
+		if !json.Valid(b.Content) {
+			return nil, fmt.Errorf("json content is not valid JSON")
+		}
+		return b.Content, nil
+	case BodyBase64:
+		var encoded string
+		if err := json.Unmarshal(b.Content, &encoded); err != nil {
+			return nil, fmt.Errorf("base64 content must be a string: %w", err)
+		}
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("base64 content decoding error: %w", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unknown body type %q", b.Type)
+	}
+}
+
+// ResetServerFields clears everything but the fields a client sets when it
+// creates a task, so that a request body cannot set the status, the
+// idempotency key or the other fields the server keeps.
+func (t *Task) ResetServerFields() {
+	*t = Task{
+		Method:      t.Method,
+		URL:         t.URL,
+		Headers:     t.Headers,
+		Body:        t.Body,
+		Retry:       t.Retry,
+		RunAt:       t.RunAt,
+		Delay:       t.Delay,
+		Options:     t.Options,
+		Priority:    t.Priority,
+		Tags:        t.Tags,
+		CallbackURL: t.CallbackURL,
+		Assertions:  t.Assertions,
+	}
+}
+
+// StampStatus records the time the task reaches the status: queued_at each
+// time it enters the queue, started_at when its first attempt starts and
+// finished_at when it is done, failed or cancelled.
+func (t *Task) StampStatus(status string, now time.Time) {
+	switch status {
+	case New, Retrying:
+		t.QueuedAt = &now
+	case In_process:
+		if t.StartedAt == nil {
+			t.StartedAt = &now
+		}
+	case Done, Error, Cancelled:
+		t.FinishedAt = &now
+	}
+	t.UpdateDuration()
+}
+
+// UpdateDuration computes the duration of a finished task from its
+// timestamps.
+func (t *Task) UpdateDuration() {
+	if t.StartedAt != nil && t.FinishedAt != nil {
+		t.Duration = Duration(t.FinishedAt.Sub(*t.StartedAt))
+	}
+}
+
+// RequestHeaders returns the headers the task is sent with: its own headers and
+// the Content-Type of its body when they do not set one.
+func (t *Task) RequestHeaders() http.Header {
+	header := make(http.Header, len(t.Headers)+1)
+	for key, value := range t.Headers {
+		header.Set(key, value)
+	}
+	if t.Body != nil && header.Get("Content-Type") == "" {
+		header.Set("Content-Type", t.Body.MediaType())
+	}
+	return header
+}
+
+// MediaType returns the Content-Type that should accompany the body.
+func (b *Body) MediaType() string {
+	if b.ContentType != "" {
+		return b.ContentType
+	}
+	switch b.Type {
+	case BodyJSON:
+		return "application/json"
+	case BodyBase64:
+		return "application/octet-stream"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
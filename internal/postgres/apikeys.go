@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"MyFirstGoApp/internal/model"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const apiKeyColumns = "id, name, prefix, key_hash, scopes, tenant, created_at, revoked_at"
+
+func (s *PostgreSQLStorage) AddAPIKey(key model.APIKey) (id int64, err error) {
+	err = s.db.QueryRow(`
+    INSERT INTO api_keys (name, prefix, key_hash, scopes, tenant)
+    VALUES ($1, $2, $3, $4, $5)
+    RETURNING id;
+    `, key.Name, key.Prefix, key.Hash, pq.Array(key.Scopes), key.Tenant).Scan(&id)
+	return id, err
+}
+
+func (s *PostgreSQLStorage) GetAPIKeyByHash(hash string) (model.APIKey, error) {
+	return scanAPIKey(s.db.QueryRow("SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = $1", hash))
+}
+
+func (s *PostgreSQLStorage) GetAllAPIKeys(tenant string) ([]model.APIKey, error) {
+	rows, err := s.db.Query("SELECT "+apiKeyColumns+" FROM api_keys WHERE tenant = $1 ORDER BY id", tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []model.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey revokes an active key of the tenant. It returns sql.ErrNoRows
+// when the tenant has no active key with the ID.
+func (s *PostgreSQLStorage) RevokeAPIKey(tenant string, id int64, revokedAt time.Time) error {
+	res, err := s.db.Exec("UPDATE api_keys SET revoked_at = $1 WHERE tenant = $2 AND id = $3 AND revoked_at IS NULL",
+		revokedAt, tenant, id)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func scanAPIKey(row scanner) (key model.APIKey, err error) {
+	var scopes pq.StringArray
+	var revokedAt sql.NullTime
+	err = row.Scan(&key.ID, &key.Name, &key.Prefix, &key.Hash, &scopes, &key.Tenant, &key.CreatedAt, &revokedAt)
+	if err != nil {
+		return
+	}
+	key.Scopes = scopes
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, nil
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"MyFirstGoApp/internal/model"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+func (s *PostgreSQLStorage) AddTaskAttempt(attempt model.TaskAttempt) error {
+	requestJSON, err := json.Marshal(attempt.Request)
+	if err != nil {
+		return err
+	}
+	responseJSON, err := nullableJSON(attempt.Response)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+    INSERT INTO task_attempts (task_id, attempt, started_at, finished_at, duration_ns, request, response, error)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+    `, attempt.TaskID, attempt.Attempt, attempt.StartedAt, attempt.FinishedAt, int64(attempt.Duration),
+		string(requestJSON), responseJSON, nullableString(attempt.Error))
+	return err
+}
+
+func (s *PostgreSQLStorage) GetTaskAttempts(tenant string, taskID int64) ([]model.TaskAttempt, error) {
+	rows, err := s.db.Query(`
+    SELECT a.id, a.task_id, a.attempt, a.started_at, a.finished_at, a.duration_ns, a.request, a.response, a.error
+    FROM task_attempts a JOIN tasks t ON t.id = a.task_id
+    WHERE a.task_id = $1 AND t.tenant = $2 ORDER BY a.id;
+    `, taskID, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := []model.TaskAttempt{}
+	for rows.Next() {
+		var attempt model.TaskAttempt
+		var durationNs int64
+		var requestJSON string
+		var responseJSON, attemptErr sql.NullString
+		err := rows.Scan(&attempt.ID, &attempt.TaskID, &attempt.Attempt, &attempt.StartedAt, &attempt.FinishedAt,
+			&durationNs, &requestJSON, &responseJSON, &attemptErr)
+		if err != nil {
+			return nil, err
+		}
+		attempt.Duration = model.Duration(time.Duration(durationNs))
+		err = json.Unmarshal([]byte(requestJSON), &attempt.Request)
+		if err != nil {
+			return nil, err
+		}
+		if responseJSON.Valid {
+			err = json.Unmarshal([]byte(responseJSON.String), &attempt.Response)
+			if err != nil {
+				return nil, err
+			}
+		}
+		attempt.Error = attemptErr.String
+		attempts = append(attempts, attempt)
+	}
+	return attempts, rows.Err()
+}
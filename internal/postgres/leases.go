@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"MyFirstGoApp/internal/model"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// UpdateTaskLease records which instance holds the task and until when.
+func (s *PostgreSQLStorage) UpdateTaskLease(task *model.Task, owner string, expiresAt time.Time) error {
+	_, err := s.db.Exec("UPDATE tasks SET lease_owner = $1, lease_expires_at = $2 WHERE id = $3",
+		owner, expiresAt, task.ID)
+	if err != nil {
+		return fmt.Errorf("error updating task lease: %w", err)
+	}
+	task.LeaseOwner = owner
+	task.LeaseExpiresAt = &expiresAt
+	return nil
+}
+
+// ExtendTaskLeases moves the expiry of the leases the owner holds on the
+// tasks that are still in process.
+func (s *PostgreSQLStorage) ExtendTaskLeases(owner string, ids []int64, expiresAt time.Time) error {
+	_, err := s.db.Exec(`
+    UPDATE tasks SET lease_expires_at = $1
+    WHERE lease_owner = $2 AND id = ANY($3) AND status = $4;
+    `, expiresAt, owner, pq.Array(ids), model.In_process)
+	if err != nil {
+		return fmt.Errorf("error extending task leases: %w", err)
+	}
+	return nil
+}
+
+// GetExpiredTasks returns the tasks in process whose lease expired before
+// now, including tasks that never got a lease.
+func (s *PostgreSQLStorage) GetExpiredTasks(now time.Time) ([]model.Task, error) {
+	return s.queryTasks("SELECT "+taskColumns+" FROM tasks WHERE status = $1 AND (lease_expires_at IS NULL OR lease_expires_at < $2)",
+		model.In_process, now)
+}
+
+// ReclaimTask takes a task with an expired lease away from its owner and
+// gives it the status, unless the lease has been extended in the meantime. It
+// reports whether the task was reclaimed.
+func (s *PostgreSQLStorage) ReclaimTask(task *model.Task, status string) (bool, error) {
+	res, err := s.db.Exec(`
+    WITH updated AS (
+        UPDATE tasks SET status = $1, next_retry_at = $2, lease_owner = NULL, lease_expires_at = NULL,
+            queued_at = $6, finished_at = $7
+        WHERE id = $3 AND status = $4 AND lease_expires_at IS NOT DISTINCT FROM $5
+        RETURNING id
+    )
+    INSERT INTO task_transitions (task_id, from_status, to_status)
+    SELECT id, $4, $1 FROM updated;
+    `, status, task.NextRetryAt, task.ID, model.In_process, task.LeaseExpiresAt, task.QueuedAt, task.FinishedAt)
+	if err != nil {
+		return false, fmt.Errorf("error reclaiming task: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rows == 0 {
+		return false, nil
+	}
+	task.Status = status
+	task.LeaseOwner = ""
+	task.LeaseExpiresAt = nil
+	return true, nil
+}
+
+// GetPendingTasks returns the new and retrying tasks.
+func (s *PostgreSQLStorage) GetPendingTasks() ([]model.Task, error) {
+	return s.queryTasks("SELECT "+taskColumns+" FROM tasks WHERE status IN ($1, $2) ORDER BY id", model.New, model.Retrying)
+}
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0002_add_column.up.sql":     {Data: []byte("ALTER TABLE t ADD COLUMN c INT;")},
+		"migrations/0002_add_column.down.sql":   {Data: []byte("ALTER TABLE t DROP COLUMN c;")},
+		"migrations/0001_create_table.up.sql":   {Data: []byte("CREATE TABLE t (id INT);")},
+		"migrations/0001_create_table.down.sql": {Data: []byte("DROP TABLE t;")},
+		"migrations/README.md":                  {Data: []byte("ignored")},
+		"migrations/0010_create_other.up.sql":   {Data: []byte("CREATE TABLE o (id INT);")},
+		"migrations/0010_create_other.down.sql": {Data: []byte("DROP TABLE o;")},
+	}
+
+	migrations, err := loadMigrations(fsys, "migrations")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(migrations) != 3 {
+		t.Fatalf("Expected 3 migrations, got %d", len(migrations))
+	}
+	expectedVersions := []int{1, 2, 10}
+	for i, migration := range migrations {
+		if migration.Version != expectedVersions[i] {
+			t.Errorf("Expected version %d at position %d, got %d", expectedVersions[i], i, migration.Version)
+		}
+	}
+	if migrations[1].Name != "add_column" || migrations[1].Down != "ALTER TABLE t DROP COLUMN c;" {
+		t.Errorf("Unexpected migration %+v", migrations[1])
+	}
+}
+
+func TestLoadMigrationsMissingDown(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0001_create_table.up.sql": {Data: []byte("CREATE TABLE t (id INT);")},
+	}
+	if _, err := loadMigrations(fsys, "migrations"); err == nil {
+		t.Error("Expected error for migration without down script, got nil")
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("Expected migration version %d, got %d", i+1, migration.Version)
+		}
+	}
+}
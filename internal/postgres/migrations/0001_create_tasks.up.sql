@@ -0,0 +1,17 @@
+-- The table as created by the code before migrations; a database from that
+-- time keeps its table and only gets the columns added since.
+CREATE TABLE IF NOT EXISTS tasks (
+    id SERIAL PRIMARY KEY,
+    method VARCHAR(10),
+    url VARCHAR(255),
+    headers JSONB,
+    status VARCHAR(20),
+    response JSONB
+);
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS body JSONB;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS retry JSONB;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS next_retry_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS tasks_pending_idx ON tasks (id) WHERE status IN ('new', 'retrying');
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS tasks_scheduled_idx;
+
+ALTER TABLE tasks DROP COLUMN run_at;
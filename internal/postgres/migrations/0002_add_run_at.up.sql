@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN run_at TIMESTAMPTZ;
+
+CREATE INDEX tasks_scheduled_idx ON tasks (run_at) WHERE status = 'scheduled';
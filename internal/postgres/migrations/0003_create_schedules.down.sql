@@ -0,0 +1,3 @@
+ALTER TABLE tasks DROP COLUMN schedule_id;
+
+DROP TABLE schedules;
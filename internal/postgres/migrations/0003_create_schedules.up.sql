@@ -0,0 +1,16 @@
+CREATE TABLE schedules (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    cron TEXT NOT NULL,
+    timezone TEXT NOT NULL DEFAULT '',
+    task JSONB NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at TIMESTAMPTZ,
+    last_run_at TIMESTAMPTZ
+);
+
+CREATE INDEX schedules_due_idx ON schedules (next_run_at) WHERE enabled;
+
+ALTER TABLE tasks ADD COLUMN schedule_id INTEGER REFERENCES schedules (id) ON DELETE SET NULL;
+
+CREATE INDEX tasks_schedule_id_idx ON tasks (schedule_id);
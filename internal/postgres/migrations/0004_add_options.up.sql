@@ -0,0 +1 @@
+ALTER TABLE tasks ADD COLUMN options JSONB;
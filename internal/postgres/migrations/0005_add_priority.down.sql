@@ -0,0 +1,3 @@
+ALTER TABLE tasks DROP COLUMN created_at;
+
+ALTER TABLE tasks DROP COLUMN priority;
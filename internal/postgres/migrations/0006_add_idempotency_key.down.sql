@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS tasks_idempotency_key_idx;
+
+ALTER TABLE tasks DROP COLUMN request_hash;
+
+ALTER TABLE tasks DROP COLUMN idempotency_key;
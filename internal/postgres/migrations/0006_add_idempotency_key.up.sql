@@ -0,0 +1,5 @@
+ALTER TABLE tasks ADD COLUMN idempotency_key TEXT;
+
+ALTER TABLE tasks ADD COLUMN request_hash TEXT;
+
+CREATE UNIQUE INDEX tasks_idempotency_key_idx ON tasks (idempotency_key) WHERE idempotency_key IS NOT NULL;
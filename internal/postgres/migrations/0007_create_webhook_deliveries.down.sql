@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS webhook_deliveries;
+
+ALTER TABLE tasks DROP COLUMN callback_url;
@@ -0,0 +1,15 @@
+ALTER TABLE tasks ADD COLUMN callback_url TEXT;
+
+CREATE TABLE webhook_deliveries (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempt INTEGER NOT NULL,
+    status_code INTEGER,
+    error TEXT,
+    success BOOLEAN NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX webhook_deliveries_task_id_idx ON webhook_deliveries (task_id);
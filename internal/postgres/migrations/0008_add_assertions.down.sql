@@ -0,0 +1,3 @@
+ALTER TABLE tasks DROP COLUMN assertion_results;
+
+ALTER TABLE tasks DROP COLUMN assertions;
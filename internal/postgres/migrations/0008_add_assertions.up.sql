@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN assertions JSONB;
+
+ALTER TABLE tasks ADD COLUMN assertion_results JSONB;
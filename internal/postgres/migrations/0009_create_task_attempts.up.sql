@@ -0,0 +1,13 @@
+CREATE TABLE task_attempts (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL,
+    duration_ns BIGINT NOT NULL,
+    request JSONB NOT NULL,
+    response JSONB,
+    error TEXT
+);
+
+CREATE INDEX task_attempts_task_id_idx ON task_attempts (task_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS tasks_lease_idx;
+
+ALTER TABLE tasks DROP COLUMN lease_expires_at;
+
+ALTER TABLE tasks DROP COLUMN lease_owner;
@@ -0,0 +1,5 @@
+ALTER TABLE tasks ADD COLUMN lease_owner TEXT;
+
+ALTER TABLE tasks ADD COLUMN lease_expires_at TIMESTAMPTZ;
+
+CREATE INDEX tasks_lease_idx ON tasks (lease_expires_at) WHERE status = 'in_process';
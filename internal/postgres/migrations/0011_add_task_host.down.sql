@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS tasks_url_prefix_idx;
+
+DROP INDEX IF EXISTS tasks_priority_idx;
+
+DROP INDEX IF EXISTS tasks_host_created_at_idx;
+
+DROP INDEX IF EXISTS tasks_status_created_at_idx;
+
+DROP INDEX IF EXISTS tasks_created_at_idx;
+
+ALTER TABLE tasks DROP COLUMN host;
@@ -0,0 +1,13 @@
+ALTER TABLE tasks ADD COLUMN host TEXT;
+
+UPDATE tasks SET host = lower(substring(url from '^[^:]+://(?:[^@/]*@)?([^:/?#]+)'));
+
+CREATE INDEX tasks_created_at_idx ON tasks (created_at, id);
+
+CREATE INDEX tasks_status_created_at_idx ON tasks (status, created_at, id);
+
+CREATE INDEX tasks_host_created_at_idx ON tasks (host, created_at, id);
+
+CREATE INDEX tasks_priority_idx ON tasks (priority, id);
+
+CREATE INDEX tasks_url_prefix_idx ON tasks (url varchar_pattern_ops);
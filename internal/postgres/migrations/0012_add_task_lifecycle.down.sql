@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS task_transitions;
+
+ALTER TABLE tasks DROP COLUMN finished_at;
+
+ALTER TABLE tasks DROP COLUMN started_at;
+
+ALTER TABLE tasks DROP COLUMN queued_at;
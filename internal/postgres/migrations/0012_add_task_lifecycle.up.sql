@@ -0,0 +1,15 @@
+ALTER TABLE tasks ADD COLUMN queued_at TIMESTAMPTZ;
+
+ALTER TABLE tasks ADD COLUMN started_at TIMESTAMPTZ;
+
+ALTER TABLE tasks ADD COLUMN finished_at TIMESTAMPTZ;
+
+CREATE TABLE task_transitions (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    from_status TEXT,
+    to_status TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX task_transitions_task_id_idx ON task_transitions (task_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS tasks_tags_idx;
+
+ALTER TABLE tasks DROP COLUMN tags;
@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN tags TEXT[];
+
+CREATE INDEX tasks_tags_idx ON tasks USING GIN (tags);
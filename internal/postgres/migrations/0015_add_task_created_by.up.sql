@@ -0,0 +1 @@
+ALTER TABLE tasks ADD COLUMN created_by TEXT;
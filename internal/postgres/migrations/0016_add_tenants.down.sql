@@ -0,0 +1,20 @@
+DROP INDEX IF EXISTS schedules_tenant_idx;
+DROP INDEX IF EXISTS tasks_tenant_status_idx;
+DROP INDEX IF EXISTS tasks_tenant_id_idx;
+
+DROP INDEX IF EXISTS tasks_idempotency_key_idx;
+-- Tenants may have used the same idempotency keys; the oldest task keeps its
+-- key, the others lose theirs.
+UPDATE tasks SET idempotency_key = NULL, request_hash = NULL
+WHERE id IN (
+    SELECT id FROM (
+        SELECT id, row_number() OVER (PARTITION BY idempotency_key ORDER BY id) AS n
+        FROM tasks WHERE idempotency_key IS NOT NULL
+    ) AS keyed
+    WHERE n > 1
+);
+CREATE UNIQUE INDEX tasks_idempotency_key_idx ON tasks (idempotency_key) WHERE idempotency_key IS NOT NULL;
+
+ALTER TABLE api_keys DROP COLUMN tenant;
+ALTER TABLE schedules DROP COLUMN tenant;
+ALTER TABLE tasks DROP COLUMN tenant;
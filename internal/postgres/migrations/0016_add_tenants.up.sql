@@ -0,0 +1,15 @@
+-- Existing rows belong to the default tenant; new rows name their tenant.
+ALTER TABLE tasks ADD COLUMN tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE tasks ALTER COLUMN tenant DROP DEFAULT;
+ALTER TABLE schedules ADD COLUMN tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE schedules ALTER COLUMN tenant DROP DEFAULT;
+ALTER TABLE api_keys ADD COLUMN tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE api_keys ALTER COLUMN tenant DROP DEFAULT;
+
+-- Idempotency keys are unique per tenant.
+DROP INDEX IF EXISTS tasks_idempotency_key_idx;
+CREATE UNIQUE INDEX tasks_idempotency_key_idx ON tasks (tenant, idempotency_key) WHERE idempotency_key IS NOT NULL;
+
+CREATE INDEX tasks_tenant_id_idx ON tasks (tenant, id);
+CREATE INDEX tasks_tenant_status_idx ON tasks (tenant, status);
+CREATE INDEX schedules_tenant_idx ON schedules (tenant);
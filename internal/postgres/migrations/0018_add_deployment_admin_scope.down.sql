@@ -0,0 +1,3 @@
+UPDATE api_keys SET scopes = array_append(scopes, 'tasks:admin')
+WHERE 'deployment:admin' = ANY (scopes) AND NOT 'tasks:admin' = ANY (scopes);
+UPDATE api_keys SET scopes = array_remove(scopes, 'deployment:admin');
@@ -0,0 +1,4 @@
+-- Admin keys of the default tenant, such as the bootstrap key, keep managing
+-- the whole deployment.
+UPDATE api_keys SET scopes = array_append(scopes, 'deployment:admin')
+WHERE tenant = 'default' AND 'tasks:admin' = ANY (scopes) AND NOT 'deployment:admin' = ANY (scopes);
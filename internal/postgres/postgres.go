@@ -5,14 +5,18 @@ import (
 	"MyFirstGoApp/internal/storage"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+// uniqueViolation is the Postgres error code of a unique constraint violation.
+const uniqueViolation = "23505"
+
 type PostgreSQLConfig struct {
 	Host     string
 	Port     string
@@ -34,26 +38,47 @@ func GetEnv(env string, fallback string) string {
 }
 
 func NewPostgreSQLStorage() (storage.Storage, error) {
-	config := PostgreSQLConfig{
+	db, err := OpenDB()
+	if err != nil {
+		return nil, err //log.Fatal(err)
+	}
+	return NewStorage(db), nil
+}
+
+// NewStorage returns a storage on top of an already opened database, so that
+// it can share the connection pool with the task queue.
+func NewStorage(db *sql.DB) *PostgreSQLStorage {
+	return &PostgreSQLStorage{db: db}
+}
+
+func ConfigFromEnv() PostgreSQLConfig {
+	return PostgreSQLConfig{
 		Host:     GetEnv("DB_HOST", "db"),
 		Port:     GetEnv("DB_PORT", "5432"),
 		Username: GetEnv("DB_USER", "postgresql"),
 		Password: GetEnv("DB_PASSWORD", "postgresql"),
 		Database: GetEnv("DB_NAME", "postgresql"),
 	}
+}
 
-	db, err := ConnectToDB(config)
+// OpenDB connects to the database configured by the DB_* environment
+// variables and applies pending migrations unless DB_AUTO_MIGRATE is false.
+func OpenDB() (*sql.DB, error) {
+	db, err := ConnectToDB(ConfigFromEnv())
 	if err != nil {
-		return nil, err //log.Fatal(err)
+		return nil, err
 	}
 
-	err = CreateTable(db)
-	if err != nil {
-		return nil, err //log.Fatal(err)
+	if GetEnv("DB_AUTO_MIGRATE", "true") != "false" {
+		err = MigrateUp(db)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
 	}
 
 	log.Println("Connection to PostgreSQL database established successfully!")
-	return &PostgreSQLStorage{db: db}, nil
+	return db, nil
 }
 
 func ConnectToDB(config PostgreSQLConfig) (*sql.DB, error) {
@@ -74,47 +99,106 @@ func ConnectToDB(config PostgreSQLConfig) (*sql.DB, error) {
 	return db, nil
 }
 
-func CreateTable(db *sql.DB) error {
-	_, err := db.Exec(`
-        DROP TABLE IF EXISTS tasks CASCADE;
-    `)
+func (s *PostgreSQLStorage) AddTask(task model.Task) (id int64, err error) {
+	return insertTask(s.db, task)
+}
+
+// AddTasks inserts all tasks in one transaction: either every task is stored
+// or none is.
+func (s *PostgreSQLStorage) AddTasks(tasks []model.Task) ([]int64, error) {
+	tx, err := s.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to drop table 'tasks': %w", err)
+		return nil, err
 	}
-	_, err = db.Exec(`
-        CREATE TABLE IF NOT EXISTS tasks (
-            id SERIAL PRIMARY KEY,
-            method VARCHAR(10),
-            url VARCHAR(255),
-            headers JSONB,
-            status VARCHAR(20),
-            response JSONB
-        );
-    `)
-	if err != nil {
-		return fmt.Errorf("failed to create table 'tasks': %w", err)
+	defer tx.Rollback()
+
+	ids := make([]int64, 0, len(tasks))
+	for i, task := range tasks {
+		id, err := insertTask(tx, task)
+		if err != nil {
+			return nil, fmt.Errorf("adding task %d of the batch: %w", i, err)
+		}
+		ids = append(ids, id)
 	}
-	return err
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
 }
 
-func (s *PostgreSQLStorage) AddTask(task model.Task) (id int64, err error) {
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func insertTask(db queryRower, task model.Task) (id int64, err error) {
 	headersJSON, err := json.Marshal(task.Headers)
 	if err != nil {
 		return 0, err
 	}
+	bodyJSON, err := nullableJSON(task.Body)
+	if err != nil {
+		return 0, err
+	}
+	retryJSON, err := nullableJSON(task.Retry)
+	if err != nil {
+		return 0, err
+	}
+	optionsJSON, err := nullableJSON(task.Options)
+	if err != nil {
+		return 0, err
+	}
+	assertionsJSON, err := nullableJSON(task.Assertions)
+	if err != nil {
+		return 0, err
+	}
 
-	row := s.db.QueryRow(`
-    INSERT INTO tasks (method, url, headers, status)
-    VALUES ($1, $2, $3, $4)
-    RETURNING id;
-    `, task.Method, task.URL, string(headersJSON), task.Status)
+	row := db.QueryRow(`
+    WITH inserted AS (
+        INSERT INTO tasks (method, url, headers, body, status, retry, run_at, schedule_id, options, priority,
+            idempotency_key, request_hash, callback_url, assertions, host, queued_at, tags, created_by, tenant)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+        RETURNING id, status
+    ), transition AS (
+        INSERT INTO task_transitions (task_id, to_status) SELECT id, status FROM inserted
+    )
+    SELECT id FROM inserted;
+    `, task.Method, task.URL, string(headersJSON), bodyJSON, task.Status, retryJSON, task.RunAt, task.ScheduleID,
+		optionsJSON, task.Priority, nullableString(task.IdempotencyKey), nullableString(task.RequestHash),
+		nullableString(task.CallbackURL), assertionsJSON, nullableString(taskHost(task.URL)), task.QueuedAt, pq.Array(task.Tags),
+		nullableString(task.CreatedBy), task.Tenant)
 
 	err = row.Scan(&id)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation && pqErr.Constraint == "tasks_idempotency_key_idx" {
+		return 0, storage.ErrDuplicateKey
+	}
 	return id, err
 }
 
-func (s *PostgreSQLStorage) GetAllTasks() ([]model.Task, error) {
-	rows, err := s.db.Query("SELECT method, url, headers, id, status, response FROM tasks")
+func (s *PostgreSQLStorage) GetTaskByIdempotencyKey(tenant string, key string) (model.Task, error) {
+	row := s.db.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE tenant = $1 AND idempotency_key = $2", tenant, key)
+	return scanTask(row)
+}
+
+func (s *PostgreSQLStorage) GetTasksByStatus(status string) ([]model.Task, error) {
+	return s.queryTasks("SELECT "+taskColumns+" FROM tasks WHERE status = $1 ORDER BY id", status)
+}
+
+func (s *PostgreSQLStorage) GetTasksBySchedule(tenant string, scheduleID int64) ([]model.Task, error) {
+	return s.queryTasks("SELECT "+taskColumns+" FROM tasks WHERE tenant = $1 AND schedule_id = $2 ORDER BY id", tenant, scheduleID)
+}
+
+// CountUnfinishedTasks returns the number of tasks of the tenant that are not
+// done, failed or cancelled yet.
+func (s *PostgreSQLStorage) CountUnfinishedTasks(tenant string) (count int, err error) {
+	err = s.db.QueryRow("SELECT count(*) FROM tasks WHERE tenant = $1 AND status = ANY($2)",
+		tenant, pq.Array(model.UnfinishedStatuses)).Scan(&count)
+	return count, err
+}
+
+func (s *PostgreSQLStorage) queryTasks(query string, args ...any) ([]model.Task, error) {
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -122,70 +206,33 @@ func (s *PostgreSQLStorage) GetAllTasks() ([]model.Task, error) {
 
 	var tasks []model.Task
 	for rows.Next() {
-		var task model.Task
-		var headers sql.NullString
-		var responseJSON sql.NullString
-		err = rows.Scan(&task.Method, &task.URL, &headers, &task.ID, &task.Status, &responseJSON)
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		if headers.Valid {
-			err = json.Unmarshal([]byte(headers.String), &task.Headers)
-			if err != nil {
-				return nil, err
-			}
-		}
-
-		if responseJSON.Valid {
-			var responseData model.ResponseData
-			json.Unmarshal([]byte(responseJSON.String), &responseData)
-			task.Response = responseData
-		}
-
 		tasks = append(tasks, task)
 	}
+	return tasks, rows.Err()
+}
 
-	return tasks, nil
-
+// Close closes the database connections.
+func (s *PostgreSQLStorage) Close() error {
+	return s.db.Close()
 }
 
-func (s *PostgreSQLStorage) CleanStorage() error {
-	_, err := s.db.Exec(`
-		TRUNCATE tasks CASCADE;
-	`)
+// CleanStorage deletes all tasks of the tenant together with their history.
+func (s *PostgreSQLStorage) CleanStorage(tenant string) error {
+	_, err := s.db.Exec("DELETE FROM tasks WHERE tenant = $1", tenant)
 	return err
 }
 
-func (s *PostgreSQLStorage) GetTaskByID(id int64) (task model.Task, err error) {
-	row := s.db.QueryRow("SELECT id, method, url, headers, status, response FROM tasks WHERE id = $1", id)
-	var headersJSON, responseJSON sql.NullString
-	err = row.Scan(&task.ID, &task.Method, &task.URL, &headersJSON, &task.Status, &responseJSON)
-	if err != nil {
-		return
-	}
-
-	if headersJSON.Valid {
-		err = json.Unmarshal([]byte(headersJSON.String), &task.Headers)
-		if err != nil {
-			return
-		}
-	}
-
-	if responseJSON.Valid {
-		var responseData model.ResponseData
-		err = json.Unmarshal([]byte(responseJSON.String), &responseData)
-		if err != nil {
-			return
-		}
-		task.Response = responseData
-	}
-
-	return task, nil
+func (s *PostgreSQLStorage) GetTaskByID(tenant string, id int64) (task model.Task, err error) {
+	row := s.db.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE tenant = $1 AND id = $2", tenant, id)
+	return scanTask(row)
 }
 
-func (s *PostgreSQLStorage) DeleteTaskByID(id int64) (status int64, err error) {
-	res, _ := s.db.Exec("DELETE FROM tasks WHERE id = $1", id)
+func (s *PostgreSQLStorage) DeleteTaskByID(tenant string, id int64) (status int64, err error) {
+	res, _ := s.db.Exec("DELETE FROM tasks WHERE tenant = $1 AND id = $2", tenant, id)
 
 	rows, err := res.RowsAffected()
 	if err != nil {
@@ -205,9 +252,21 @@ func (s *PostgreSQLStorage) DeleteTaskByID(id int64) (status int64, err error) {
 	return status, err
 }
 
+// UpdateTaskStatus stores the status and the lifecycle timestamps of the task
+// and records the transition from its previous status.
 func (s *PostgreSQLStorage) UpdateTaskStatus(task *model.Task, status string) error {
 	task.Status = status
-	_, err := s.db.Exec("UPDATE tasks SET status = $1 WHERE id = $2", status, task.ID)
+	_, err := s.db.Exec(`
+    WITH previous AS (
+        SELECT id, status FROM tasks WHERE id = $1 FOR UPDATE
+    ), updated AS (
+        UPDATE tasks SET status = $2, queued_at = $3, started_at = $4, finished_at = $5
+        FROM previous WHERE tasks.id = previous.id
+        RETURNING previous.status AS from_status
+    )
+    INSERT INTO task_transitions (task_id, from_status, to_status)
+    SELECT $1, from_status, $2 FROM updated WHERE from_status IS DISTINCT FROM $2;
+    `, task.ID, status, task.QueuedAt, task.StartedAt, task.FinishedAt)
 	if err != nil {
 		return fmt.Errorf("error updating task status: %w", err)
 	} else {
@@ -216,6 +275,33 @@ func (s *PostgreSQLStorage) UpdateTaskStatus(task *model.Task, status string) er
 	return err
 }
 
+// UpdateTaskStatusIf changes the status only when the stored status is still
+// current, and reports whether it did.
+func (s *PostgreSQLStorage) UpdateTaskStatusIf(task *model.Task, current string, status string) (bool, error) {
+	res, err := s.db.Exec(`
+    WITH updated AS (
+        UPDATE tasks SET status = $2, queued_at = $4, started_at = $5, finished_at = $6
+        WHERE id = $1 AND status = $3
+        RETURNING id
+    )
+    INSERT INTO task_transitions (task_id, from_status, to_status)
+    SELECT id, $3, $2 FROM updated;
+    `, task.ID, status, current, task.QueuedAt, task.StartedAt, task.FinishedAt)
+	if err != nil {
+		return false, fmt.Errorf("error updating task status: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rows == 0 {
+		return false, nil
+	}
+	task.Status = status
+	log.Printf("Task ID %d status updated from %s to %s\n", task.ID, current, status)
+	return true, nil
+}
+
 func (s *PostgreSQLStorage) UpdateTaskResponse(task *model.Task, responseData *model.ResponseData) error {
 	responseJSON, err := json.Marshal(responseData)
 	if err != nil {
@@ -224,3 +310,148 @@ func (s *PostgreSQLStorage) UpdateTaskResponse(task *model.Task, responseData *m
 	_, err = s.db.Exec("UPDATE tasks SET response = $1 WHERE id = $2", string(responseJSON), task.ID)
 	return err
 }
+
+func (s *PostgreSQLStorage) UpdateTaskAssertionResults(task *model.Task) error {
+	resultsJSON, err := nullableJSON(task.AssertionResults)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec("UPDATE tasks SET assertion_results = $1 WHERE id = $2", resultsJSON, task.ID)
+	return err
+}
+
+func (s *PostgreSQLStorage) UpdateTaskAttempts(task *model.Task) error {
+	_, err := s.db.Exec("UPDATE tasks SET attempts = $1, next_retry_at = $2 WHERE id = $3",
+		task.Attempts, task.NextRetryAt, task.ID)
+	if err != nil {
+		return fmt.Errorf("error updating task attempts: %w", err)
+	}
+	return nil
+}
+
+const taskColumns = "id, method, url, headers, body, status, response, retry, attempts, next_retry_at, run_at, schedule_id, options, priority, idempotency_key, request_hash, callback_url, assertions, assertion_results, lease_owner, lease_expires_at, created_at, queued_at, started_at, finished_at, tags, created_by, tenant"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask reads a row selected with taskColumns.
+func scanTask(row scanner) (task model.Task, err error) {
+	var headersJSON, bodyJSON, responseJSON, retryJSON, optionsJSON, idempotencyKey, requestHash, callbackURL, leaseOwner, createdBy sql.NullString
+	var assertionsJSON, assertionResultsJSON sql.NullString
+	var nextRetryAt, runAt, leaseExpiresAt, createdAt, queuedAt, startedAt, finishedAt sql.NullTime
+	var scheduleID sql.NullInt64
+	var tags pq.StringArray
+	err = row.Scan(&task.ID, &task.Method, &task.URL, &headersJSON, &bodyJSON, &task.Status, &responseJSON,
+		&retryJSON, &task.Attempts, &nextRetryAt, &runAt, &scheduleID, &optionsJSON, &task.Priority,
+		&idempotencyKey, &requestHash, &callbackURL, &assertionsJSON, &assertionResultsJSON,
+		&leaseOwner, &leaseExpiresAt, &createdAt, &queuedAt, &startedAt, &finishedAt, &tags, &createdBy, &task.Tenant)
+	if err != nil {
+		return
+	}
+
+	if headersJSON.Valid {
+		err = json.Unmarshal([]byte(headersJSON.String), &task.Headers)
+		if err != nil {
+			return
+		}
+	}
+
+	if bodyJSON.Valid {
+		err = json.Unmarshal([]byte(bodyJSON.String), &task.Body)
+		if err != nil {
+			return
+		}
+	}
+
+	if responseJSON.Valid {
+		err = json.Unmarshal([]byte(responseJSON.String), &task.Response)
+		if err != nil {
+			return
+		}
+	}
+
+	if retryJSON.Valid {
+		err = json.Unmarshal([]byte(retryJSON.String), &task.Retry)
+		if err != nil {
+			return
+		}
+	}
+
+	if optionsJSON.Valid {
+		err = json.Unmarshal([]byte(optionsJSON.String), &task.Options)
+		if err != nil {
+			return
+		}
+	}
+
+	if assertionsJSON.Valid {
+		err = json.Unmarshal([]byte(assertionsJSON.String), &task.Assertions)
+		if err != nil {
+			return
+		}
+	}
+
+	if assertionResultsJSON.Valid {
+		err = json.Unmarshal([]byte(assertionResultsJSON.String), &task.AssertionResults)
+		if err != nil {
+			return
+		}
+	}
+
+	if nextRetryAt.Valid {
+		task.NextRetryAt = &nextRetryAt.Time
+	}
+
+	if runAt.Valid {
+		task.RunAt = &runAt.Time
+	}
+
+	if scheduleID.Valid {
+		task.ScheduleID = &scheduleID.Int64
+	}
+
+	task.IdempotencyKey = idempotencyKey.String
+	task.RequestHash = requestHash.String
+	task.CallbackURL = callbackURL.String
+	task.LeaseOwner = leaseOwner.String
+	if leaseExpiresAt.Valid {
+		task.LeaseExpiresAt = &leaseExpiresAt.Time
+	}
+	if createdAt.Valid {
+		task.CreatedAt = &createdAt.Time
+	}
+	if queuedAt.Valid {
+		task.QueuedAt = &queuedAt.Time
+	}
+	if startedAt.Valid {
+		task.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		task.FinishedAt = &finishedAt.Time
+	}
+	task.UpdateDuration()
+	if len(tags) > 0 {
+		task.Tags = tags
+	}
+	task.CreatedBy = createdBy.String
+
+	return task, nil
+}
+
+// nullableString stores empty strings as SQL NULL.
+func nullableString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// nullableJSON marshals v for a JSONB column, storing SQL NULL for nil values.
+func nullableJSON(v any) (sql.NullString, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	if string(data) == "null" {
+		return sql.NullString{}, nil
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/storage"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// QueryTasks returns one page of the tasks matching the query. Pages are
+// continued with a cursor instead of an offset, so that deep pages cost as
+// much as the first one and tasks created meanwhile do not shift them.
+func (s *PostgreSQLStorage) QueryTasks(query storage.TaskQuery) ([]model.Task, error) {
+	sqlQuery, args, err := buildTaskQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return s.queryTasks(sqlQuery, args...)
+}
+
+// buildTaskQuery translates the query to SQL and its arguments.
+func buildTaskQuery(query storage.TaskQuery) (string, []any, error) {
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	conditions := []string{"tenant = " + arg(query.Tenant)}
+
+	if len(query.Statuses) > 0 {
+		conditions = append(conditions, "status = ANY("+arg(pq.Array(query.Statuses))+")")
+	}
+	if query.Method != "" {
+		conditions = append(conditions, "method = "+arg(strings.ToUpper(query.Method)))
+	}
+	if query.Host != "" {
+		conditions = append(conditions, "host = "+arg(strings.ToLower(query.Host)))
+	}
+	if len(query.Tags) > 0 {
+		conditions = append(conditions, "tags @> "+arg(pq.Array(query.Tags)))
+	}
+	if query.URLPrefix != "" {
+		conditions = append(conditions, "url LIKE "+arg(escapeLike(query.URLPrefix)+"%"))
+	}
+	if query.CreatedAfter != nil {
+		conditions = append(conditions, "created_at >= "+arg(*query.CreatedAfter))
+	}
+	if query.CreatedBefore != nil {
+		conditions = append(conditions, "created_at < "+arg(*query.CreatedBefore))
+	}
+
+	var column string
+	var cursorValue any
+	switch query.SortBy {
+	case "", storage.SortByID:
+	case storage.SortByCreatedAt:
+		column = "created_at"
+		if query.After != nil {
+			cursorValue = query.After.CreatedAt
+		}
+	case storage.SortByPriority:
+		column = "priority"
+		if query.After != nil {
+			cursorValue = query.After.Priority
+		}
+	default:
+		return "", nil, fmt.Errorf("unknown sort order %q", query.SortBy)
+	}
+
+	direction, comparison := "ASC", ">"
+	if query.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if query.After != nil {
+		if column == "" {
+			conditions = append(conditions, "id "+comparison+" "+arg(query.After.ID))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s, %s)",
+				column, comparison, arg(cursorValue), arg(query.After.ID)))
+		}
+	}
+
+	sqlQuery := "SELECT " + taskColumns + " FROM tasks"
+	if len(conditions) > 0 {
+		sqlQuery += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	if column != "" {
+		sqlQuery += " ORDER BY " + column + " " + direction + ", id " + direction
+	} else {
+		sqlQuery += " ORDER BY id " + direction
+	}
+	if query.Limit > 0 {
+		sqlQuery += " LIMIT " + arg(query.Limit)
+	}
+	return sqlQuery, args, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern.
+func escapeLike(pattern string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(pattern)
+}
+
+// taskHost returns the lowercased host of a task URL, stored for filtering.
+func taskHost(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/queue"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// pendingCondition selects tasks that are waiting to be sent, including
+// scheduled tasks whose time has come.
+const pendingCondition = `((status IN ('new', 'retrying') AND (next_retry_at IS NULL OR next_retry_at <= now()))
+        OR (status = 'scheduled' AND run_at <= now()))`
+
+// PostgreSQLQueue is a queue.TaskQueue that keeps no state of its own: pending
+// tasks are the rows of the tasks table, and workers claim them with
+// SELECT ... FOR UPDATE SKIP LOCKED. Tasks survive restarts, and several
+// instances of the application can share one database.
+//
+// A claimed task has a lease from the start, so that it is recovered if the
+// instance stops before a worker takes it over.
+//
+// Tasks are claimed in the same order as in queue.PriorityQueue: a higher
+// priority first, with every aging interval of waiting worth one priority
+// level.
+type PostgreSQLQueue struct {
+	db           *sql.DB
+	pollInterval time.Duration
+	aging        time.Duration
+	leaseTimeout time.Duration
+	wake         chan struct{}
+	done         chan struct{}
+	closeOnce    sync.Once
+	heldBack     func() []string
+}
+
+// NewPostgreSQLQueue creates the queue. A claimed task gets a lease of
+// leaseTimeout, which the worker then takes over and renews.
+func NewPostgreSQLQueue(db *sql.DB, pollInterval time.Duration, aging time.Duration, leaseTimeout time.Duration) queue.TaskQueue {
+	return &PostgreSQLQueue{
+		db:           db,
+		pollInterval: pollInterval,
+		aging:        aging,
+		leaseTimeout: leaseTimeout,
+		wake:         make(chan struct{}, 1),
+		done:         make(chan struct{}),
+	}
+}
+
+// Durable reports that pending tasks are kept in the tasks table.
+func (q *PostgreSQLQueue) Durable() bool {
+	return true
+}
+
+// Enqueque wakes up a waiting worker. The task itself is already stored in
+// the tasks table and is claimed from there.
+func (q *PostgreSQLQueue) Enqueque(task model.Task) {
+	q.notify()
+}
+
+// Dequeque blocks until a pending task is claimed. It returns an empty task
+// once the queue is closed.
+func (q *PostgreSQLQueue) Dequeque() model.Task {
+	task, _ := q.next()
+	return task
+}
+
+func (q *PostgreSQLQueue) Start(num int, process func(model.Task)) {
+	for i := 0; i < num; i++ {
+		go func() {
+			for {
+				task, ok := q.next()
+				if !ok {
+					return
+				}
+				process(task)
+			}
+		}()
+	}
+}
+
+func (q *PostgreSQLQueue) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+func (q *PostgreSQLQueue) Size() int {
+	var size int
+	err := q.db.QueryRow("SELECT count(*) FROM tasks WHERE " + pendingCondition).Scan(&size)
+	if err != nil {
+		log.Printf("Error counting pending tasks: %v\n", err)
+	}
+	return size
+}
+
+func (q *PostgreSQLQueue) Close() {
+	q.closeOnce.Do(func() {
+		close(q.done)
+	})
+}
+
+// HoldBack sets the function that returns the tenants whose tasks are not
+// claimed. It is set before the workers start.
+func (q *PostgreSQLQueue) HoldBack(tenants func() []string) {
+	q.heldBack = tenants
+}
+
+// Wake has a waiting worker try to claim a task.
+func (q *PostgreSQLQueue) Wake() {
+	q.notify()
+}
+
+func (q *PostgreSQLQueue) next() (model.Task, bool) {
+	ticker := time.NewTicker(q.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-q.done:
+			return model.Task{}, false
+		default:
+		}
+
+		task, err := q.claim()
+		if err == nil {
+			// There may be more pending tasks, let another worker look.
+			q.notify()
+			return task, true
+		}
+		if err != sql.ErrNoRows {
+			log.Printf("Error claiming a task from the queue: %v\n", err)
+		}
+
+		select {
+		case <-q.done:
+			return model.Task{}, false
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *PostgreSQLQueue) claim() (model.Task, error) {
+	// An empty array rather than NULL, which would exclude every tenant.
+	heldBack := []string{}
+	if q.heldBack != nil {
+		heldBack = append(heldBack, q.heldBack()...)
+	}
+	row := q.db.QueryRow(`
+    WITH previous AS (
+        SELECT id, status FROM tasks
+        WHERE `+pendingCondition+` AND NOT (tenant = ANY($4))
+        ORDER BY EXTRACT(EPOCH FROM COALESCE(next_retry_at, run_at, created_at)) - priority * $2::float8, id
+        FOR UPDATE SKIP LOCKED
+        LIMIT 1
+    ), claimed AS (
+        UPDATE tasks SET status = $1, lease_owner = NULL, lease_expires_at = now() + make_interval(secs => $3::float8),
+            started_at = COALESCE(tasks.started_at, now())
+        FROM previous WHERE tasks.id = previous.id
+        RETURNING tasks.*, previous.status AS from_status
+    ), transition AS (
+        INSERT INTO task_transitions (task_id, from_status, to_status) SELECT id, from_status, $1 FROM claimed
+    )
+    SELECT `+taskColumns+` FROM claimed;
+    `, model.In_process, q.aging.Seconds(), q.leaseTimeout.Seconds(), pq.Array(heldBack))
+	return scanTask(row)
+}
+
+func (q *PostgreSQLQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/queue"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var taskRowColumns = []string{"id", "method", "url", "headers", "body", "status", "response", "retry", "attempts", "next_retry_at", "run_at", "schedule_id", "options", "priority", "idempotency_key", "request_hash", "callback_url", "assertions", "assertion_results", "lease_owner", "lease_expires_at", "created_at", "queued_at", "started_at", "finished_at", "tags", "created_by", "tenant"}
+
+func TestPostgreSQLQueueDequeue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FOR UPDATE SKIP LOCKED .* UPDATE tasks SET status = \\$1, lease_owner = NULL, .* INSERT INTO task_transitions").
+		WithArgs(model.In_process, 60.0, 30.0, "{}").
+		WillReturnRows(sqlmock.NewRows(taskRowColumns).
+			AddRow(7, "GET", "https://example.com", `{"Accept":"*/*"}`, nil, model.In_process, nil, nil, 0, nil, nil, nil, nil, 3, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, "team-a"))
+
+	q := NewPostgreSQLQueue(db, time.Second, time.Minute, 30*time.Second)
+	task := q.Dequeque()
+
+	if task.ID != 7 {
+		t.Errorf("Expected task ID 7, got %d", task.ID)
+	}
+	if task.Status != model.In_process {
+		t.Errorf("Expected status %s, got %s", model.In_process, task.Status)
+	}
+	if task.Priority != 3 {
+		t.Errorf("Expected priority 3, got %d", task.Priority)
+	}
+	if task.Headers["Accept"] != "*/*" {
+		t.Errorf("Expected Accept header, got %v", task.Headers)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPostgreSQLQueueHoldBack(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("AND NOT \\(tenant = ANY\\(\\$4\\)\\)").
+		WithArgs(model.In_process, 60.0, 30.0, "{\"team-a\"}").
+		WillReturnRows(sqlmock.NewRows(taskRowColumns).
+			AddRow(8, "GET", "https://example.com", nil, nil, model.In_process, nil, nil, 0, nil, nil, nil, nil, 0, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, "team-b"))
+
+	q := NewPostgreSQLQueue(db, time.Second, time.Minute, 30*time.Second)
+	q.(queue.TenantLimited).HoldBack(func() []string { return []string{"team-a"} })
+	if task := q.Dequeque(); task.ID != 8 {
+		t.Errorf("Expected task ID 8, got %d", task.ID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPostgreSQLQueueClose(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("UPDATE tasks SET status").
+		WillReturnRows(sqlmock.NewRows(taskRowColumns))
+
+	q := NewPostgreSQLQueue(db, time.Hour, time.Minute, 30*time.Second)
+	done := make(chan model.Task)
+	go func() {
+		done <- q.Dequeque()
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	q.Close()
+
+	select {
+	case task := <-done:
+		if task.ID != 0 {
+			t.Errorf("Expected empty task after close, got ID %d", task.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Dequeque did not return after close")
+	}
+}
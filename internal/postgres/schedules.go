@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"MyFirstGoApp/internal/model"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const scheduleColumns = "id, name, cron, timezone, task, enabled, next_run_at, last_run_at, last_error, tenant"
+
+func (s *PostgreSQLStorage) AddSchedule(schedule model.Schedule) (id int64, err error) {
+	taskJSON, err := json.Marshal(schedule.Task)
+	if err != nil {
+		return 0, err
+	}
+
+	row := s.db.QueryRow(`
+    INSERT INTO schedules (name, cron, timezone, task, enabled, next_run_at, tenant)
+    VALUES ($1, $2, $3, $4, $5, $6, $7)
+    RETURNING id;
+    `, schedule.Name, schedule.Cron, schedule.Timezone, string(taskJSON), schedule.Enabled, schedule.NextRunAt, schedule.Tenant)
+
+	err = row.Scan(&id)
+	return id, err
+}
+
+func (s *PostgreSQLStorage) GetAllSchedules(tenant string) ([]model.Schedule, error) {
+	return s.querySchedules("SELECT "+scheduleColumns+" FROM schedules WHERE tenant = $1 ORDER BY id", tenant)
+}
+
+func (s *PostgreSQLStorage) GetScheduleByID(tenant string, id int64) (model.Schedule, error) {
+	row := s.db.QueryRow("SELECT "+scheduleColumns+" FROM schedules WHERE tenant = $1 AND id = $2", tenant, id)
+	return scanSchedule(row)
+}
+
+// GetDueSchedules returns the enabled schedules whose next firing is not
+// later than now.
+func (s *PostgreSQLStorage) GetDueSchedules(now time.Time) ([]model.Schedule, error) {
+	return s.querySchedules("SELECT "+scheduleColumns+" FROM schedules WHERE enabled AND next_run_at <= $1 ORDER BY next_run_at", now)
+}
+
+func (s *PostgreSQLStorage) UpdateSchedule(schedule model.Schedule) error {
+	taskJSON, err := json.Marshal(schedule.Task)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.db.Exec(`
+    UPDATE schedules SET name = $1, cron = $2, timezone = $3, task = $4, enabled = $5, next_run_at = $6
+    WHERE id = $7 AND tenant = $8;
+    `, schedule.Name, schedule.Cron, schedule.Timezone, string(taskJSON), schedule.Enabled, schedule.NextRunAt,
+		schedule.ID, schedule.Tenant)
+	if err != nil {
+		return fmt.Errorf("error updating schedule: %w", err)
+	}
+	return expectRows(res)
+}
+
+// ClaimScheduleRun moves the next firing of a schedule from prevRunAt to
+// nextRunAt and reports whether this caller won the firing. It lets several
+// instances share the schedules without firing them twice.
+func (s *PostgreSQLStorage) ClaimScheduleRun(id int64, prevRunAt time.Time, nextRunAt time.Time) (bool, error) {
+	res, err := s.db.Exec(`
+    UPDATE schedules SET next_run_at = $1, last_run_at = now()
+    WHERE id = $2 AND enabled AND next_run_at = $3;
+    `, nextRunAt, id, prevRunAt)
+	if err != nil {
+		return false, fmt.Errorf("error claiming schedule run: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// SetScheduleError records why the last firing of a schedule created no task,
+// or clears the record when message is empty.
+func (s *PostgreSQLStorage) SetScheduleError(id int64, message string) error {
+	_, err := s.db.Exec("UPDATE schedules SET last_error = NULLIF($1, '') WHERE id = $2", message, id)
+	if err != nil {
+		return fmt.Errorf("error recording schedule error: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStorage) DeleteScheduleByID(tenant string, id int64) error {
+	res, err := s.db.Exec("DELETE FROM schedules WHERE tenant = $1 AND id = $2", tenant, id)
+	if err != nil {
+		return err
+	}
+	return expectRows(res)
+}
+
+func (s *PostgreSQLStorage) querySchedules(query string, args ...any) ([]model.Schedule, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []model.Schedule
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+func scanSchedule(row scanner) (schedule model.Schedule, err error) {
+	var taskJSON string
+	var nextRunAt, lastRunAt sql.NullTime
+	var lastError sql.NullString
+	err = row.Scan(&schedule.ID, &schedule.Name, &schedule.Cron, &schedule.Timezone, &taskJSON,
+		&schedule.Enabled, &nextRunAt, &lastRunAt, &lastError, &schedule.Tenant)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal([]byte(taskJSON), &schedule.Task)
+	if err != nil {
+		return
+	}
+
+	if nextRunAt.Valid {
+		schedule.NextRunAt = &nextRunAt.Time
+	}
+	if lastRunAt.Valid {
+		schedule.LastRunAt = &lastRunAt.Time
+	}
+	schedule.LastError = lastError.String
+	return schedule, nil
+}
+
+// expectRows turns an update or delete that matched nothing into sql.ErrNoRows.
+func expectRows(res sql.Result) error {
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"MyFirstGoApp/internal/model"
+	"database/sql"
+)
+
+// GetTaskTransitions returns the status history of a task, oldest first. The
+// transitions are recorded by the statements that change the status.
+func (s *PostgreSQLStorage) GetTaskTransitions(tenant string, taskID int64) ([]model.TaskTransition, error) {
+	rows, err := s.db.Query(`
+    SELECT tr.id, tr.task_id, tr.from_status, tr.to_status, tr.created_at
+    FROM task_transitions tr JOIN tasks t ON t.id = tr.task_id
+    WHERE tr.task_id = $1 AND t.tenant = $2 ORDER BY tr.id;
+    `, taskID, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transitions := []model.TaskTransition{}
+	for rows.Next() {
+		var transition model.TaskTransition
+		var from sql.NullString
+		err := rows.Scan(&transition.ID, &transition.TaskID, &from, &transition.To, &transition.At)
+		if err != nil {
+			return nil, err
+		}
+		transition.From = from.String
+		transitions = append(transitions, transition)
+	}
+	return transitions, rows.Err()
+}
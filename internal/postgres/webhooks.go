@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"MyFirstGoApp/internal/model"
+	"database/sql"
+)
+
+func (s *PostgreSQLStorage) AddWebhookDelivery(delivery model.WebhookDelivery) error {
+	_, err := s.db.Exec(`
+    INSERT INTO webhook_deliveries (task_id, url, status, attempt, status_code, error, success, created_at)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+    `, delivery.TaskID, delivery.URL, delivery.Status, delivery.Attempt,
+		sql.NullInt64{Int64: int64(delivery.StatusCode), Valid: delivery.StatusCode != 0},
+		nullableString(delivery.Error), delivery.Success, delivery.CreatedAt)
+	return err
+}
+
+func (s *PostgreSQLStorage) GetWebhookDeliveries(tenant string, taskID int64) ([]model.WebhookDelivery, error) {
+	rows, err := s.db.Query(`
+    SELECT d.id, d.task_id, d.url, d.status, d.attempt, d.status_code, d.error, d.success, d.created_at
+    FROM webhook_deliveries d JOIN tasks t ON t.id = d.task_id
+    WHERE d.task_id = $1 AND t.tenant = $2 ORDER BY d.id;
+    `, taskID, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []model.WebhookDelivery{}
+	for rows.Next() {
+		var delivery model.WebhookDelivery
+		var statusCode sql.NullInt64
+		var deliveryErr sql.NullString
+		err := rows.Scan(&delivery.ID, &delivery.TaskID, &delivery.URL, &delivery.Status, &delivery.Attempt,
+			&statusCode, &deliveryErr, &delivery.Success, &delivery.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		delivery.StatusCode = int(statusCode.Int64)
+		delivery.Error = deliveryErr.String
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,256 @@
+package queue
+
+import (
+	"MyFirstGoApp/internal/model"
+	"container/heap"
+	"log"
+	"slices"
+	"sync"
+	"time"
+)
+
+// PriorityQueue is a bounded in-memory queue that hands out tasks with a
+// higher Priority first. To keep low-priority tasks from starving, every
+// aging interval a task waits counts as one extra priority level. Producers
+// blocked on a full queue are let in in the same order, so an urgent task
+// does not wait behind the tasks of lower priority enqueued before it. The
+// tasks of held back tenants are passed over until they are no longer held
+// back.
+type PriorityQueue struct {
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	items    priorityHeap
+	waiting  waiterHeap
+	capacity int
+	aging    time.Duration
+	seq      uint64
+	closed   bool
+	now      func() time.Time
+	heldBack func() []string
+}
+
+func NewPriorityQueue(size int, aging time.Duration) TaskQueue {
+	q := &PriorityQueue{
+		capacity: size,
+		aging:    aging,
+		now:      time.Now,
+	}
+	q.notEmpty = sync.NewCond(&q.mu)
+	return q
+}
+
+// Enqueque blocks while the queue is full.
+func (q *PriorityQueue) Enqueque(task model.Task) {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		log.Printf("Task with ID %d dropped, the queue is closed\n", task.ID)
+		return
+	}
+
+	q.seq++
+	item := priorityItem{task: task, score: q.score(task), seq: q.seq}
+	if len(q.items) < q.capacity {
+		heap.Push(&q.items, item)
+		q.notEmpty.Signal()
+		q.mu.Unlock()
+		return
+	}
+
+	// take moves the task into the queue when it is the most urgent of the
+	// waiting ones and a slot is free.
+	w := &waiter{item: item, admitted: make(chan bool, 1)}
+	heap.Push(&q.waiting, w)
+	// A worker idle while the queued tasks are held back can take it.
+	q.notEmpty.Signal()
+	q.mu.Unlock()
+	if !<-w.admitted {
+		log.Printf("Task with ID %d dropped, the queue is closed\n", task.ID)
+	}
+}
+
+// Dequeque blocks until a task is available. It returns an empty task once
+// the queue is closed and drained.
+func (q *PriorityQueue) Dequeque() model.Task {
+	task, _ := q.next()
+	return task
+}
+
+func (q *PriorityQueue) Start(num int, process func(model.Task)) {
+	for i := 0; i < num; i++ {
+		go func() {
+			for {
+				task, ok := q.next()
+				if !ok {
+					return
+				}
+				process(task)
+			}
+		}()
+	}
+}
+
+func (q *PriorityQueue) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+func (q *PriorityQueue) Size() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Close stops accepting tasks; the workers finish the queued ones and exit.
+func (q *PriorityQueue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.notEmpty.Broadcast()
+	for _, w := range q.waiting {
+		w.admitted <- false
+	}
+	q.waiting = nil
+}
+
+// HoldBack sets the function that returns the tenants whose tasks are
+// passed over. Once the queue is closed the tasks of every tenant are handed
+// out.
+func (q *PriorityQueue) HoldBack(tenants func() []string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.heldBack = tenants
+}
+
+func (q *PriorityQueue) Wake() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.notEmpty.Broadcast()
+}
+
+func (q *PriorityQueue) next() (model.Task, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		if item, ok := q.take(); ok {
+			return item.task, true
+		}
+		if q.closed && len(q.items) == 0 {
+			return model.Task{}, false
+		}
+		q.notEmpty.Wait()
+	}
+}
+
+// take removes the most urgent task of a tenant that is not held back. When
+// the queue has none, a producer waiting for room may have one, which is then
+// handed out directly. The caller holds q.mu.
+func (q *PriorityQueue) take() (priorityItem, bool) {
+	skip := func(task model.Task) bool { return false }
+	if q.heldBack != nil && !q.closed {
+		if tenants := q.heldBack(); len(tenants) > 0 {
+			skip = func(task model.Task) bool { return slices.Contains(tenants, task.Tenant) }
+		}
+	}
+
+	if item, ok := popUnless(&q.items, func(item priorityItem) bool { return skip(item.task) }); ok {
+		if len(q.waiting) > 0 {
+			w := heap.Pop(&q.waiting).(*waiter)
+			heap.Push(&q.items, w.item)
+			w.admitted <- true
+		}
+		return item, true
+	}
+	if w, ok := popUnless(&q.waiting, func(w *waiter) bool { return skip(w.item.task) }); ok {
+		w.admitted <- true
+		return w.item, true
+	}
+	return priorityItem{}, false
+}
+
+// popUnless pops the first element of h that skip does not reject and puts
+// the rejected ones back.
+func popUnless[T any](h heap.Interface, skip func(T) bool) (T, bool) {
+	var skipped []T
+	defer func() {
+		for _, x := range skipped {
+			heap.Push(h, x)
+		}
+	}()
+	for h.Len() > 0 {
+		x := heap.Pop(h).(T)
+		if !skip(x) {
+			return x, true
+		}
+		skipped = append(skipped, x)
+	}
+	var zero T
+	return zero, false
+}
+
+// score orders the tasks: the lower the score, the sooner the task is
+// dequeued. Shifting the enqueue time back by one aging interval per priority
+// level keeps the order fixed while the tasks wait, and a task that has waited
+// long enough overtakes newer tasks of a higher priority.
+func (q *PriorityQueue) score(task model.Task) int64 {
+	return q.now().UnixNano() - int64(task.Priority)*int64(q.aging)
+}
+
+type priorityItem struct {
+	task  model.Task
+	score int64
+	seq   uint64
+}
+
+func (a priorityItem) before(b priorityItem) bool {
+	if a.score != b.score {
+		return a.score < b.score
+	}
+	return a.seq < b.seq
+}
+
+type priorityHeap []priorityItem
+
+func (h priorityHeap) Len() int { return len(h) }
+
+func (h priorityHeap) Less(i, j int) bool { return h[i].before(h[j]) }
+
+func (h priorityHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *priorityHeap) Push(x any) {
+	*h = append(*h, x.(priorityItem))
+}
+
+func (h *priorityHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// waiter is a producer blocked on a full queue. admitted reports whether its
+// task got into the queue or was dropped by Close.
+type waiter struct {
+	item     priorityItem
+	admitted chan bool
+}
+
+type waiterHeap []*waiter
+
+func (h waiterHeap) Len() int { return len(h) }
+
+func (h waiterHeap) Less(i, j int) bool { return h[i].item.before(h[j].item) }
+
+func (h waiterHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *waiterHeap) Push(x any) {
+	*h = append(*h, x.(*waiter))
+}
+
+func (h *waiterHeap) Pop() any {
+	old := *h
+	n := len(old)
+	w := old[n-1]
+	*h = old[:n-1]
+	return w
+}
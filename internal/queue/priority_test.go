@@ -0,0 +1,187 @@
+package queue
+
+import (
+	"MyFirstGoApp/internal/model"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPriorityQueueOrder(t *testing.T) {
+	q := NewPriorityQueue(10, time.Minute)
+	q.Enqueque(model.Task{ID: 1, Priority: 0})
+	q.Enqueque(model.Task{ID: 2, Priority: 10})
+	q.Enqueque(model.Task{ID: 3, Priority: 5})
+	q.Enqueque(model.Task{ID: 4, Priority: 10})
+
+	expected := []int64{2, 4, 3, 1}
+	for _, id := range expected {
+		task := q.Dequeque()
+		if task.ID != id {
+			t.Errorf("Expected task ID %d, got %d", id, task.ID)
+		}
+	}
+	if !q.IsEmpty() {
+		t.Errorf("Queue should be empty, got size %d", q.Size())
+	}
+}
+
+func TestPriorityQueueAging(t *testing.T) {
+	q := NewPriorityQueue(10, time.Minute).(*PriorityQueue)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+
+	q.Enqueque(model.Task{ID: 1, Priority: 0})
+	now = now.Add(3 * time.Minute)
+	q.Enqueque(model.Task{ID: 2, Priority: 2})
+	q.Enqueque(model.Task{ID: 3, Priority: 5})
+
+	expected := []int64{3, 1, 2}
+	for _, id := range expected {
+		task := q.Dequeque()
+		if task.ID != id {
+			t.Errorf("Expected task ID %d, got %d", id, task.ID)
+		}
+	}
+}
+
+func TestPriorityQueueStartAndClose(t *testing.T) {
+	q := NewPriorityQueue(10, time.Minute)
+	var mu sync.Mutex
+	var processed int
+	var wg sync.WaitGroup
+	wg.Add(5)
+
+	for i := 1; i <= 5; i++ {
+		q.Enqueque(model.Task{ID: int64(i), Priority: i})
+	}
+	q.Start(2, func(task model.Task) {
+		mu.Lock()
+		processed++
+		mu.Unlock()
+		wg.Done()
+	})
+	wg.Wait()
+	q.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if processed != 5 {
+		t.Errorf("Expected 5 processed tasks, got %d", processed)
+	}
+
+	done := make(chan model.Task)
+	go func() {
+		done <- q.Dequeque()
+	}()
+	select {
+	case task := <-done:
+		if task.ID != 0 {
+			t.Errorf("Expected empty task after close, got ID %d", task.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Dequeque blocked on a closed queue")
+	}
+}
+
+func TestPriorityQueueBlockingEnqueue(t *testing.T) {
+	q := NewPriorityQueue(1, time.Minute)
+	q.Enqueque(model.Task{ID: 1})
+
+	enqueued := make(chan struct{})
+	go func() {
+		q.Enqueque(model.Task{ID: 2})
+		close(enqueued)
+	}()
+
+	select {
+	case <-enqueued:
+		t.Fatal("Enqueque should block when queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	q.Dequeque()
+	select {
+	case <-enqueued:
+	case <-time.After(time.Second):
+		t.Fatal("Enqueque was not unblocked by dequeue")
+	}
+}
+
+func TestPriorityQueueFullLetsUrgentTaskFirst(t *testing.T) {
+	q := NewPriorityQueue(1, time.Minute).(*PriorityQueue)
+	q.Enqueque(model.Task{ID: 1})
+
+	waiting := func(n int) {
+		deadline := time.Now().Add(time.Second)
+		for {
+			q.mu.Lock()
+			blocked := len(q.waiting)
+			q.mu.Unlock()
+			if blocked == n {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected %d blocked producers, got %d", n, blocked)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	for i := 2; i <= 5; i++ {
+		go q.Enqueque(model.Task{ID: int64(i)})
+	}
+	waiting(4)
+	go q.Enqueque(model.Task{ID: 6, Priority: 10})
+	waiting(5)
+
+	expected := []int64{1, 6}
+	for _, id := range expected {
+		if task := q.Dequeque(); task.ID != id {
+			t.Errorf("Expected task ID %d, got %d", id, task.ID)
+		}
+	}
+	q.Close()
+	waiting(0)
+}
+
+func TestPriorityQueueHoldBack(t *testing.T) {
+	q := NewPriorityQueue(2, time.Minute).(*PriorityQueue)
+	var mu sync.Mutex
+	heldBack := []string{"team-a"}
+	q.HoldBack(func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return heldBack
+	})
+	q.Enqueque(model.Task{ID: 1, Tenant: "team-a", Priority: 10})
+	q.Enqueque(model.Task{ID: 2, Tenant: "team-a"})
+	// The queue is full, the task of team-b waits for room.
+	go q.Enqueque(model.Task{ID: 3, Tenant: "team-b"})
+
+	if task := q.Dequeque(); task.ID != 3 {
+		t.Errorf("Expected the waiting task of team-b to pass the held back tasks, got %d", task.ID)
+	}
+
+	dequeued := make(chan int64)
+	go func() {
+		dequeued <- q.Dequeque().ID
+	}()
+	select {
+	case id := <-dequeued:
+		t.Fatalf("Expected no task while team-a is held back, got %d", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	mu.Lock()
+	heldBack = nil
+	mu.Unlock()
+	q.Wake()
+	select {
+	case id := <-dequeued:
+		if id != 1 {
+			t.Errorf("Expected task 1 once team-a is no longer held back, got %d", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wake did not hand out the task of team-a")
+	}
+}
@@ -2,6 +2,8 @@ package queue
 
 import (
 	"MyFirstGoApp/internal/model"
+	"log"
+	"sync"
 )
 
 type TaskQueue interface {
@@ -13,28 +15,68 @@ type TaskQueue interface {
 	Close()
 }
 
+// Durable is implemented by queues that read pending tasks from storage, so
+// they need not be refilled after a restart.
+type Durable interface {
+	Durable() bool
+}
+
+// TenantLimited is implemented by queues that can pass over the tasks of some
+// tenants, so that the tasks of a tenant using its whole share of the workers
+// stay queued instead of being handed out only to be put back.
+type TenantLimited interface {
+	// HoldBack sets the function that returns the tenants whose tasks are
+	// passed over.
+	HoldBack(tenants func() []string)
+	// Wake has the idle workers look at the queue again, as a tenant may no
+	// longer be held back.
+	Wake()
+}
+
 type TasksQueue struct {
-	tasks chan model.Task
+	tasks     chan model.Task
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 func NewTasksQueue(size int) TaskQueue {
 	return &TasksQueue{
 		tasks: make(chan model.Task, size),
+		done:  make(chan struct{}),
 	}
 }
 
+// Enqueque blocks while the queue is full. A task still waiting for room
+// when the queue is closed is dropped.
 func (q *TasksQueue) Enqueque(task model.Task) {
-	q.tasks <- task
+	select {
+	case <-q.done:
+		log.Printf("Task with ID %d dropped, the queue is closed\n", task.ID)
+		return
+	default:
+	}
+	select {
+	case q.tasks <- task:
+	case <-q.done:
+		log.Printf("Task with ID %d dropped, the queue is closed\n", task.ID)
+	}
 }
 
+// Dequeque blocks until a task is available. It returns an empty task once
+// the queue is closed and drained.
 func (q *TasksQueue) Dequeque() model.Task {
-	return <-q.tasks
+	task, _ := q.next()
+	return task
 }
 
 func (q *TasksQueue) Start(num int, process func(model.Task)) {
 	for i := 0; i < num; i++ {
 		go func() {
-			for task := range q.tasks {
+			for {
+				task, ok := q.next()
+				if !ok {
+					return
+				}
 				process(task)
 			}
 		}()
@@ -48,6 +90,25 @@ func (q *TasksQueue) IsEmpty() bool {
 func (q *TasksQueue) Size() int {
 	return len(q.tasks)
 }
+
+// Close stops accepting tasks; the workers finish the queued ones and exit.
 func (q *TasksQueue) Close() {
-	close(q.tasks)
+	q.closeOnce.Do(func() {
+		close(q.done)
+	})
+}
+
+func (q *TasksQueue) next() (model.Task, bool) {
+	select {
+	case task := <-q.tasks:
+		return task, true
+	case <-q.done:
+	}
+	// The tasks queued before Close are still handed out.
+	select {
+	case task := <-q.tasks:
+		return task, true
+	default:
+		return model.Task{}, false
+	}
 }
@@ -213,6 +213,31 @@ func TestBlockingEnqueue(t *testing.T) {
 	}
 }
 
+func TestCloseWakesBlockedEnqueue(t *testing.T) {
+	q := NewTasksQueue(1)
+	q.Enqueque(model.Task{ID: 1, Method: "GET", URL: "https://example.com"})
+
+	done := make(chan struct{})
+	go func() {
+		q.Enqueque(model.Task{ID: 2, Method: "GET", URL: "https://example.com"})
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	q.Close()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Enqueque still blocked after Close")
+	}
+	if task := q.Dequeque(); task.ID != 1 {
+		t.Errorf("Expected the queued task to be handed out after Close, got %d", task.ID)
+	}
+	if task := q.Dequeque(); task.ID != 0 {
+		t.Errorf("Expected the waiting task to be dropped, got %d", task.ID)
+	}
+}
+
 func TestHTTPTaskProcessing(t *testing.T) {
 	q := NewTasksQueue(10)
 	var processedCount int
@@ -0,0 +1,134 @@
+package quota
+
+import (
+	"MyFirstGoApp/internal/model"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// AnyTenant is the tenant of the quota that applies to the tenants without a
+// quota of their own.
+const AnyTenant = "*"
+
+// Quotas keeps the quotas of the tenants and counts the workers busy with the
+// tasks of each tenant.
+type Quotas struct {
+	mu      sync.Mutex
+	quotas  map[string]model.TenantQuota
+	workers int
+	busy    map[string]int
+}
+
+func New(quotas []model.TenantQuota) *Quotas {
+	q := &Quotas{
+		quotas: make(map[string]model.TenantQuota),
+		busy:   make(map[string]int),
+	}
+	for _, quota := range quotas {
+		q.quotas[quota.Tenant] = quota
+	}
+	return q
+}
+
+// Quotas returns the configured quotas ordered by tenant.
+func (q *Quotas) Quotas() []model.TenantQuota {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	quotas := make([]model.TenantQuota, 0, len(q.quotas))
+	for _, quota := range q.quotas {
+		quotas = append(quotas, quota)
+	}
+	slices.SortFunc(quotas, func(a, b model.TenantQuota) int {
+		return strings.Compare(a.Tenant, b.Tenant)
+	})
+	return quotas
+}
+
+// Set adds the quota or replaces the one of the same tenant.
+func (q *Quotas) Set(quota model.TenantQuota) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.quotas[quota.Tenant] = quota
+}
+
+// Delete removes the quota of the tenant and reports whether it existed.
+func (q *Quotas) Delete(tenant string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.quotas[tenant]; !ok {
+		return false
+	}
+	delete(q.quotas, tenant)
+	return true
+}
+
+// For returns the quota that applies to the tenant.
+func (q *Quotas) For(tenant string) model.TenantQuota {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.quotaFor(tenant)
+}
+
+func (q *Quotas) quotaFor(tenant string) model.TenantQuota {
+	if quota, ok := q.quotas[tenant]; ok {
+		return quota
+	}
+	quota := q.quotas[AnyTenant]
+	quota.Tenant = tenant
+	return quota
+}
+
+// SetWorkers sets the number of workers the worker shares are fractions of.
+func (q *Quotas) SetWorkers(workers int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.workers = workers
+}
+
+// AcquireWorker takes a worker for a task of the tenant and returns the
+// function that frees it. It reports false, taking nothing, while the tenant
+// already uses its share of the workers.
+func (q *Quotas) AcquireWorker(tenant string) (func(), bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if limit := q.maxWorkers(q.quotaFor(tenant)); limit > 0 && q.busy[tenant] >= limit {
+		return nil, false
+	}
+	q.busy[tenant]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			q.busy[tenant]--
+			if q.busy[tenant] == 0 {
+				delete(q.busy, tenant)
+			}
+		})
+	}, true
+}
+
+// FullTenants returns the tenants that use their whole share of the workers.
+func (q *Quotas) FullTenants() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var tenants []string
+	for tenant, busy := range q.busy {
+		if limit := q.maxWorkers(q.quotaFor(tenant)); limit > 0 && busy >= limit {
+			tenants = append(tenants, tenant)
+		}
+	}
+	return tenants
+}
+
+// maxWorkers turns a worker share into a number of workers, at least one, or
+// 0 for no limit.
+func (q *Quotas) maxWorkers(quota model.TenantQuota) int {
+	if quota.WorkerShare <= 0 || q.workers <= 0 {
+		return 0
+	}
+	return max(1, int(math.Floor(quota.WorkerShare*float64(q.workers))))
+}
@@ -0,0 +1,91 @@
+package quota
+
+import (
+	"MyFirstGoApp/internal/model"
+	"testing"
+)
+
+func TestFor(t *testing.T) {
+	q := New([]model.TenantQuota{
+		{Tenant: AnyTenant, MaxQueued: 100},
+		{Tenant: "team-a", MaxQueued: 10, WorkerShare: 0.5},
+	})
+	if quota := q.For("team-a"); quota.MaxQueued != 10 || quota.WorkerShare != 0.5 {
+		t.Errorf("Expected the quota of team-a, got %+v", quota)
+	}
+	if quota := q.For("team-b"); quota.Tenant != "team-b" || quota.MaxQueued != 100 {
+		t.Errorf("Expected the * quota for team-b, got %+v", quota)
+	}
+
+	if !q.Delete(AnyTenant) || q.Delete(AnyTenant) {
+		t.Error("Expected the * quota to be deleted once")
+	}
+	if quota := q.For("team-b"); quota.MaxQueued != 0 || quota.WorkerShare != 0 {
+		t.Errorf("Expected no limits without a * quota, got %+v", quota)
+	}
+	q.Set(model.TenantQuota{Tenant: "team-b", MaxQueued: 5})
+	if quotas := q.Quotas(); len(quotas) != 2 || quotas[0].Tenant != "team-a" || quotas[1].Tenant != "team-b" {
+		t.Errorf("Expected the quotas ordered by tenant, got %+v", quotas)
+	}
+}
+
+func TestAcquireWorker(t *testing.T) {
+	q := New([]model.TenantQuota{{Tenant: "team-a", WorkerShare: 0.25}})
+	q.SetWorkers(10)
+
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		release, ok := q.AcquireWorker("team-a")
+		if !ok {
+			t.Fatalf("Expected worker %d to be acquired", i+1)
+		}
+		releases = append(releases, release)
+	}
+	if _, ok := q.AcquireWorker("team-a"); ok {
+		t.Error("Expected the share of team-a to be used up")
+	}
+	for i := 0; i < 5; i++ {
+		if _, ok := q.AcquireWorker("team-b"); !ok {
+			t.Error("Expected team-b without a quota not to be limited")
+		}
+	}
+
+	releases[0]()
+	releases[0]()
+	if _, ok := q.AcquireWorker("team-a"); !ok {
+		t.Error("Expected a released worker to be acquired again")
+	}
+	if _, ok := q.AcquireWorker("team-a"); ok {
+		t.Error("Expected a release to free only one worker")
+	}
+}
+
+func TestFullTenants(t *testing.T) {
+	q := New([]model.TenantQuota{{Tenant: "team-a", WorkerShare: 0.2}})
+	q.SetWorkers(10)
+	release, _ := q.AcquireWorker("team-a")
+	q.AcquireWorker("team-b")
+	if tenants := q.FullTenants(); len(tenants) != 0 {
+		t.Errorf("Expected no full tenants, got %v", tenants)
+	}
+
+	q.AcquireWorker("team-a")
+	if tenants := q.FullTenants(); len(tenants) != 1 || tenants[0] != "team-a" {
+		t.Errorf("Expected team-a to be full, got %v", tenants)
+	}
+	release()
+	if tenants := q.FullTenants(); len(tenants) != 0 {
+		t.Errorf("Expected no full tenants after a release, got %v", tenants)
+	}
+}
+
+func TestAcquireWorkerAtLeastOne(t *testing.T) {
+	q := New([]model.TenantQuota{{Tenant: "team-a", WorkerShare: 0.01}})
+	q.SetWorkers(10)
+	if _, ok := q.AcquireWorker("team-a"); !ok {
+		t.Error("Expected a small share to allow one worker")
+	}
+	if _, ok := q.AcquireWorker("team-a"); ok {
+		t.Error("Expected a small share to allow only one worker")
+	}
+}
@@ -0,0 +1,221 @@
+package ratelimit
+
+import (
+	"MyFirstGoApp/internal/model"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BusyDelay is the wait TryAcquire reports for a host whose concurrency
+// limit is reached, as there is no telling when a request finishes.
+const BusyDelay = 250 * time.Millisecond
+
+// sweepInterval is how often the states of idle hosts are dropped.
+const sweepInterval = time.Minute
+
+// Limiter enforces the rate limits of outbound requests. Every host gets its
+// own token bucket and concurrency count, configured by the most specific
+// rule matching it: an exact host, then the longest *.domain, then *.
+type Limiter struct {
+	mu    sync.Mutex
+	rules map[string]model.RateLimit
+	hosts map[string]*hostState
+	swept time.Time
+}
+
+type hostState struct {
+	rule    model.RateLimit
+	limited bool
+	tokens  float64
+	last    time.Time
+	active  int
+}
+
+func New(rules []model.RateLimit) *Limiter {
+	l := &Limiter{
+		rules: make(map[string]model.RateLimit),
+		hosts: make(map[string]*hostState),
+	}
+	for _, rule := range rules {
+		l.rules[strings.ToLower(rule.Pattern)] = rule
+	}
+	return l
+}
+
+// Rules returns the configured rules ordered by pattern.
+func (l *Limiter) Rules() []model.RateLimit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rules := make([]model.RateLimit, 0, len(l.rules))
+	for _, rule := range l.rules {
+		rules = append(rules, rule)
+	}
+	slices.SortFunc(rules, func(a, b model.RateLimit) int {
+		return strings.Compare(a.Pattern, b.Pattern)
+	})
+	return rules
+}
+
+// SetRule adds the rule or replaces the one with the same pattern. Requests
+// already in flight count against the new limits.
+func (l *Limiter) SetRule(rule model.RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rule.Pattern = strings.ToLower(rule.Pattern)
+	l.rules[rule.Pattern] = rule
+	l.refresh()
+}
+
+// DeleteRule removes the rule with the pattern and reports whether it existed.
+func (l *Limiter) DeleteRule(pattern string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	pattern = strings.ToLower(pattern)
+	if _, ok := l.rules[pattern]; !ok {
+		return false
+	}
+	delete(l.rules, pattern)
+	l.refresh()
+	return true
+}
+
+// TryAcquire claims a request to host under its rule and returns the function
+// that has to be called once the request is finished. When the request is not
+// allowed yet it returns a nil release function and how long to wait before
+// trying again.
+func (l *Limiter) TryAcquire(host string) (release func(), wait time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(time.Now())
+	state := l.state(strings.ToLower(host))
+	if state == nil {
+		return func() {}, 0
+	}
+
+	now := time.Now()
+	state.refill(now)
+	wait, ok := state.take(now)
+	if !ok {
+		return nil, wait
+	}
+	return l.releaseFunc(state), 0
+}
+
+func (l *Limiter) releaseFunc(state *hostState) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			state.active--
+		})
+	}
+}
+
+// state returns the state of host, or nil when no rule limits it. The caller
+// holds l.mu.
+func (l *Limiter) state(host string) *hostState {
+	state, ok := l.hosts[host]
+	if !ok {
+		rule, limited := l.match(host)
+		if !limited {
+			return nil
+		}
+		state = &hostState{}
+		state.configure(rule, true)
+		l.hosts[host] = state
+	}
+	if !state.limited && state.active == 0 {
+		delete(l.hosts, host)
+		return nil
+	}
+	return state
+}
+
+// sweep drops the states of the hosts without requests in flight and with a
+// full bucket, which are no different from new ones, so that the hosts seen
+// once are not kept forever. The caller holds l.mu.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < sweepInterval {
+		return
+	}
+	l.swept = now
+	for host, state := range l.hosts {
+		state.refill(now)
+		if state.active == 0 && state.tokens >= float64(state.rule.Burst) {
+			delete(l.hosts, host)
+		}
+	}
+}
+
+// refresh applies changed rules to the known hosts. The caller holds l.mu.
+func (l *Limiter) refresh() {
+	for host, state := range l.hosts {
+		rule, limited := l.match(host)
+		state.configure(rule, limited)
+	}
+}
+
+func (l *Limiter) match(host string) (model.RateLimit, bool) {
+	if rule, ok := l.rules[host]; ok {
+		return rule, true
+	}
+	for suffix := host; ; {
+		dot := strings.IndexByte(suffix, '.')
+		if dot < 0 {
+			break
+		}
+		suffix = suffix[dot+1:]
+		if rule, ok := l.rules["*."+suffix]; ok {
+			return rule, true
+		}
+	}
+	rule, ok := l.rules["*"]
+	return rule, ok
+}
+
+func (s *hostState) configure(rule model.RateLimit, limited bool) {
+	if rule.Burst == 0 {
+		rule.Burst = int(math.Max(1, math.Ceil(rule.RequestsPerSecond)))
+	}
+	if s.last.IsZero() {
+		s.tokens = float64(rule.Burst)
+		s.last = time.Now()
+	}
+	s.tokens = math.Min(s.tokens, float64(rule.Burst))
+	s.rule = rule
+	s.limited = limited
+}
+
+func (s *hostState) refill(now time.Time) {
+	if s.rule.RequestsPerSecond > 0 {
+		s.tokens += now.Sub(s.last).Seconds() * s.rule.RequestsPerSecond
+		s.tokens = math.Min(s.tokens, float64(s.rule.Burst))
+	}
+	s.last = now
+}
+
+// take claims a token and a concurrency slot. When one of them is not
+// available it reports false and how long to wait for the next token, or
+// BusyDelay for a slot.
+func (s *hostState) take(now time.Time) (time.Duration, bool) {
+	if !s.limited {
+		s.active++
+		return 0, true
+	}
+	if s.rule.MaxConcurrent > 0 && s.active >= s.rule.MaxConcurrent {
+		return BusyDelay, false
+	}
+	if s.rule.RequestsPerSecond > 0 {
+		if s.tokens < 1 {
+			wait := time.Duration((1 - s.tokens) / s.rule.RequestsPerSecond * float64(time.Second))
+			return max(wait, time.Millisecond), false
+		}
+		s.tokens--
+	}
+	s.active++
+	return 0, true
+}
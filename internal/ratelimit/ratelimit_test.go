@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"MyFirstGoApp/internal/model"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	l := New([]model.RateLimit{
+		{Pattern: "api.partner.com", RequestsPerSecond: 1},
+		{Pattern: "*.partner.com", RequestsPerSecond: 2},
+		{Pattern: "*.eu.partner.com", RequestsPerSecond: 3},
+		{Pattern: "*", RequestsPerSecond: 4},
+	})
+	tests := []struct {
+		host     string
+		expected float64
+	}{
+		{"api.partner.com", 1},
+		{"www.partner.com", 2},
+		{"a.eu.partner.com", 3},
+		{"partner.com", 4},
+		{"example.com", 4},
+	}
+	for _, tt := range tests {
+		rule, ok := l.match(tt.host)
+		if !ok || rule.RequestsPerSecond != tt.expected {
+			t.Errorf("Host %s: expected rate %v, got %+v", tt.host, tt.expected, rule)
+		}
+	}
+
+	l.DeleteRule("*")
+	if _, ok := l.match("example.com"); ok {
+		t.Error("Expected no rule for example.com")
+	}
+}
+
+func TestTryAcquireUnlimitedHost(t *testing.T) {
+	l := New([]model.RateLimit{{Pattern: "api.partner.com", MaxConcurrent: 1}})
+	for i := 0; i < 3; i++ {
+		if release, wait := l.TryAcquire("example.com"); release == nil || wait != 0 {
+			t.Fatalf("Expected a request to an unlimited host to be allowed, got wait %s", wait)
+		}
+	}
+}
+
+func TestTryAcquireRate(t *testing.T) {
+	l := New([]model.RateLimit{{Pattern: "*", RequestsPerSecond: 50, Burst: 2}})
+	for i := 0; i < 2; i++ {
+		release, _ := l.TryAcquire("example.com")
+		if release == nil {
+			t.Fatalf("Expected request %d to use the burst", i+1)
+		}
+		release()
+	}
+	release, wait := l.TryAcquire("example.com")
+	if release != nil || wait <= 0 || wait > 20*time.Millisecond {
+		t.Fatalf("Expected to wait up to 20ms for the next token, got %s", wait)
+	}
+	time.Sleep(wait)
+	if release, _ := l.TryAcquire("example.com"); release == nil {
+		t.Error("Expected the request to be allowed after the wait")
+	}
+}
+
+func TestSetRuleRaisesLimit(t *testing.T) {
+	l := New([]model.RateLimit{{Pattern: "example.com", MaxConcurrent: 1}})
+	if release, _ := l.TryAcquire("example.com"); release == nil {
+		t.Fatal("Expected the first request to be allowed")
+	}
+	if release, _ := l.TryAcquire("example.com"); release != nil {
+		t.Fatal("Expected the second request to wait")
+	}
+
+	l.SetRule(model.RateLimit{Pattern: "example.com", MaxConcurrent: 2})
+	if release, _ := l.TryAcquire("example.com"); release == nil {
+		t.Error("Expected the raised limit to allow the second request")
+	}
+}
+
+func TestTryAcquire(t *testing.T) {
+	l := New([]model.RateLimit{
+		{Pattern: "api.partner.com", MaxConcurrent: 1},
+		{Pattern: "slow.partner.com", RequestsPerSecond: 10, Burst: 1},
+	})
+	release, wait := l.TryAcquire("API.partner.com")
+	if release == nil || wait != 0 {
+		t.Fatalf("Expected the first request to be allowed, got wait %s", wait)
+	}
+	if release, wait := l.TryAcquire("api.partner.com"); release != nil || wait != BusyDelay {
+		t.Errorf("Expected the second request to wait %s, got %s", BusyDelay, wait)
+	}
+	release()
+	if release, _ := l.TryAcquire("api.partner.com"); release == nil {
+		t.Error("Expected a request to be allowed after the release")
+	}
+
+	if release, _ := l.TryAcquire("slow.partner.com"); release == nil {
+		t.Fatal("Expected the burst to allow the first request")
+	}
+	if release, wait := l.TryAcquire("slow.partner.com"); release != nil || wait <= 0 || wait > 100*time.Millisecond {
+		t.Errorf("Expected to wait for the next token, got %s", wait)
+	}
+}
+
+func TestSweepIdleHosts(t *testing.T) {
+	l := New([]model.RateLimit{{Pattern: "*", RequestsPerSecond: 1000, MaxConcurrent: 1}})
+	busy, _ := l.TryAcquire("busy.example.com")
+	release, _ := l.TryAcquire("idle.example.com")
+	release()
+
+	l.mu.Lock()
+	l.swept = time.Time{}
+	l.sweep(time.Now().Add(time.Second))
+	_, idle := l.hosts["idle.example.com"]
+	_, inFlight := l.hosts["busy.example.com"]
+	l.mu.Unlock()
+	if idle {
+		t.Error("Expected the idle host to be dropped")
+	}
+	if !inFlight {
+		t.Error("Expected the host with a request in flight to be kept")
+	}
+	busy()
+}
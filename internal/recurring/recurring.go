@@ -0,0 +1,58 @@
+package recurring
+
+import (
+	"MyFirstGoApp/internal/model"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+var parser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Validate checks the cron expression, the time zone and the task template
+// of a schedule.
+func Validate(schedule model.Schedule) error {
+	if _, err := parse(schedule); err != nil {
+		return err
+	}
+	if schedule.Task.RunAt != nil {
+		return fmt.Errorf("task template must not have run_at")
+	}
+	if err := schedule.Task.Validate(); err != nil {
+		return fmt.Errorf("invalid task template: %w", err)
+	}
+	return nil
+}
+
+// Next returns the first firing time of the schedule after the given time.
+func Next(schedule model.Schedule, after time.Time) (time.Time, error) {
+	sched, err := parse(schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := sched.Next(after)
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("cron expression %q never fires", schedule.Cron)
+	}
+	return next, nil
+}
+
+func parse(schedule model.Schedule) (cron.Schedule, error) {
+	location := time.UTC
+	if schedule.Timezone != "" {
+		var err error
+		location, err = time.LoadLocation(schedule.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", schedule.Timezone, err)
+		}
+	}
+	sched, err := parser.Parse(schedule.Cron)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", schedule.Cron, err)
+	}
+	if spec, ok := sched.(*cron.SpecSchedule); ok {
+		spec.Location = location
+	}
+	return sched, nil
+}
@@ -0,0 +1,69 @@
+package recurring
+
+import (
+	"MyFirstGoApp/internal/model"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	after := time.Date(2024, 3, 10, 8, 30, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		schedule model.Schedule
+		expected time.Time
+	}{
+		{
+			name:     "EveryFiveMinutes",
+			schedule: model.Schedule{Cron: "*/5 * * * *"},
+			expected: time.Date(2024, 3, 10, 8, 35, 0, 0, time.UTC),
+		},
+		{
+			name:     "Descriptor",
+			schedule: model.Schedule{Cron: "@daily"},
+			expected: time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Timezone",
+			schedule: model.Schedule{Cron: "0 12 * * *", Timezone: "Europe/Moscow"},
+			expected: time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			next, err := Next(tc.schedule, after)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !next.Equal(tc.expected) {
+				t.Errorf("Expected %s, got %s", tc.expected, next.UTC())
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	task := model.Task{Method: "GET", URL: "https://example.com"}
+	runAt := time.Now()
+	testCases := []struct {
+		name     string
+		schedule model.Schedule
+		valid    bool
+	}{
+		{"Valid", model.Schedule{Cron: "0 * * * *", Task: task}, true},
+		{"InvalidCron", model.Schedule{Cron: "61 * * * *", Task: task}, false},
+		{"InvalidTimezone", model.Schedule{Cron: "0 * * * *", Timezone: "Mars/Olympus", Task: task}, false},
+		{"TemplateWithRunAt", model.Schedule{Cron: "0 * * * *", Task: model.Task{Method: "GET", RunAt: &runAt}}, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.schedule)
+			if tc.valid && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
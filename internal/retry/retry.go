@@ -0,0 +1,103 @@
+package retry
+
+import (
+	"MyFirstGoApp/internal/model"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"syscall"
+	"time"
+)
+
+const (
+	DefaultBaseDelay = time.Second
+	DefaultMaxDelay  = 5 * time.Minute
+)
+
+var DefaultRetryOnStatus = []int{429, 500, 502, 503, 504}
+
+// Retryable reports whether the outcome of an attempt is a failure the policy
+// allows to retry. A nil policy never retries.
+func Retryable(policy *model.RetryPolicy, resp *model.ResponseData, err error) bool {
+	if policy == nil {
+		return false
+	}
+	if err != nil {
+		kind := Classify(err)
+		if kind == "" {
+			return false
+		}
+		kinds := policy.RetryOnErrors
+		if kinds == nil {
+			kinds = model.NetworkErrors
+		}
+		return slices.Contains(kinds, kind)
+	}
+	if resp == nil {
+		return false
+	}
+	codes := policy.RetryOnStatus
+	if codes == nil {
+		codes = DefaultRetryOnStatus
+	}
+	return slices.Contains(codes, resp.StatusCode)
+}
+
+// Delay returns how long to wait before the retry that follows the given
+// number of attempts.
+func Delay(policy *model.RetryPolicy, attempts int) time.Duration {
+	base := time.Duration(policy.BaseDelay)
+	if base <= 0 {
+		base = DefaultBaseDelay
+	}
+	maxDelay := time.Duration(policy.MaxDelay)
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxDelay
+	}
+
+	delay := base
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	if policy.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * policy.Jitter * float64(delay))
+	}
+	return delay
+}
+
+// Classify maps an error returned by the HTTP client to one of the network
+// error kinds in model, or "" when it is not a network error.
+func Classify(err error) string {
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+	var netErr net.Error
+
+	switch {
+	case errors.As(err, &dnsErr):
+		return model.ErrDNS
+	case errors.As(err, &certErr), errors.As(err, &recordErr), errors.As(err, &unknownAuthority),
+		errors.As(err, &hostnameErr), errors.As(err, &invalidCert):
+		return model.ErrTLS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return model.ErrTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return model.ErrConnectionRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return model.ErrConnectionReset
+	}
+	return ""
+}
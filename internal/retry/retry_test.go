@@ -0,0 +1,109 @@
+package retry
+
+import (
+	"MyFirstGoApp/internal/model"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryable(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policy   *model.RetryPolicy
+		resp     *model.ResponseData
+		err      error
+		expected bool
+	}{
+		{"NoPolicy", nil, &model.ResponseData{StatusCode: 503}, nil, false},
+		{"DefaultStatus", &model.RetryPolicy{MaxAttempts: 3}, &model.ResponseData{StatusCode: 503}, nil, true},
+		{"SuccessStatus", &model.RetryPolicy{MaxAttempts: 3}, &model.ResponseData{StatusCode: 200}, nil, false},
+		{"CustomStatus", &model.RetryPolicy{MaxAttempts: 3, RetryOnStatus: []int{404}}, &model.ResponseData{StatusCode: 404}, nil, true},
+		{"EmptyStatusList", &model.RetryPolicy{MaxAttempts: 3, RetryOnStatus: []int{}}, &model.ResponseData{StatusCode: 503}, nil, false},
+		{"DefaultErrors", &model.RetryPolicy{MaxAttempts: 3}, nil, fmt.Errorf("send: %w", syscall.ECONNREFUSED), true},
+		{"ErrorNotListed", &model.RetryPolicy{MaxAttempts: 3, RetryOnErrors: []string{model.ErrTimeout}}, nil, fmt.Errorf("send: %w", syscall.ECONNREFUSED), false},
+		{"NotNetworkError", &model.RetryPolicy{MaxAttempts: 3}, nil, errors.New("request creation error"), false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Retryable(tc.policy, tc.resp, tc.err); got != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestDelay(t *testing.T) {
+	policy := &model.RetryPolicy{
+		MaxAttempts: 10,
+		BaseDelay:   model.Duration(100 * time.Millisecond),
+		MaxDelay:    model.Duration(time.Second),
+	}
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for i, want := range expected {
+		if got := Delay(policy, i+1); got != want {
+			t.Errorf("Attempt %d: expected delay %s, got %s", i+1, want, got)
+		}
+	}
+}
+
+func TestDelayJitter(t *testing.T) {
+	policy := &model.RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   model.Duration(time.Second),
+		Jitter:      0.5,
+	}
+	for i := 0; i < 100; i++ {
+		delay := Delay(policy, 1)
+		if delay < 500*time.Millisecond || delay > time.Second {
+			t.Fatalf("Delay %s is out of the jitter range", delay)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error creating listener: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	_, err = http.Get("http://" + addr)
+	if kind := Classify(err); kind != model.ErrConnectionRefused {
+		t.Errorf("Expected %s, got %q", model.ErrConnectionRefused, kind)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+	client := &http.Client{Timeout: 10 * time.Millisecond}
+	_, err = client.Get(server.URL)
+	if kind := Classify(err); kind != model.ErrTimeout {
+		t.Errorf("Expected %s, got %q", model.ErrTimeout, kind)
+	}
+
+	if kind := Classify(&net.DNSError{Err: "no such host", Name: "invalid"}); kind != model.ErrDNS {
+		t.Errorf("Expected %s, got %q", model.ErrDNS, kind)
+	}
+
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsServer.Close()
+	_, err = http.Get(tlsServer.URL)
+	if kind := Classify(err); kind != model.ErrTLS {
+		t.Errorf("Expected %s, got %q", model.ErrTLS, kind)
+	}
+}
@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"MyFirstGoApp/internal/model"
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// Scheduler holds tasks until their RunAt time and then hands them to the
+// release function. It keeps tasks in memory only; the caller restores them
+// from storage after a restart.
+type Scheduler struct {
+	mu      sync.Mutex
+	tasks   taskHeap
+	release func(model.Task)
+	wake    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+func New(release func(model.Task)) *Scheduler {
+	return &Scheduler{
+		release: release,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+}
+
+// Schedule adds a task that is released at task.RunAt. Tasks without RunAt
+// are released immediately.
+func (s *Scheduler) Schedule(task model.Task) {
+	s.mu.Lock()
+	heap.Push(&s.tasks, task)
+	s.mu.Unlock()
+	s.notify()
+}
+
+// Remove drops a pending task and reports whether it was found.
+func (s *Scheduler) Remove(id int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, task := range s.tasks {
+		if task.ID == id {
+			heap.Remove(&s.tasks, i)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.tasks)
+}
+
+func (s *Scheduler) Start() {
+	go s.run()
+}
+
+func (s *Scheduler) Stop() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+func (s *Scheduler) run() {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		for _, task := range s.due(time.Now()) {
+			s.release(task)
+		}
+
+		wait := time.Hour
+		s.mu.Lock()
+		if len(s.tasks) > 0 {
+			wait = time.Until(runAt(s.tasks[0]))
+		}
+		s.mu.Unlock()
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-s.done:
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *Scheduler) due(now time.Time) []model.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var tasks []model.Task
+	for len(s.tasks) > 0 && !runAt(s.tasks[0]).After(now) {
+		tasks = append(tasks, heap.Pop(&s.tasks).(model.Task))
+	}
+	return tasks
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func runAt(task model.Task) time.Time {
+	if task.RunAt == nil {
+		return time.Time{}
+	}
+	return *task.RunAt
+}
+
+type taskHeap []model.Task
+
+func (h taskHeap) Len() int           { return len(h) }
+func (h taskHeap) Less(i, j int) bool { return runAt(h[i]).Before(runAt(h[j])) }
+func (h taskHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *taskHeap) Push(x any) {
+	*h = append(*h, x.(model.Task))
+}
+
+func (h *taskHeap) Pop() any {
+	old := *h
+	n := len(old)
+	task := old[n-1]
+	*h = old[:n-1]
+	return task
+}
@@ -0,0 +1,68 @@
+package scheduler
+
+import (
+	"MyFirstGoApp/internal/model"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSchedulerReleasesInOrder(t *testing.T) {
+	var mu sync.Mutex
+	var released []int64
+	s := New(func(task model.Task) {
+		mu.Lock()
+		released = append(released, task.ID)
+		mu.Unlock()
+	})
+	s.Start()
+	defer s.Stop()
+
+	now := time.Now()
+	for i, delay := range []time.Duration{60, 20, 40} {
+		runAt := now.Add(delay * time.Millisecond)
+		s.Schedule(model.Task{ID: int64(i + 1), RunAt: &runAt})
+	}
+
+	time.Sleep(150 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []int64{2, 3, 1}
+	if len(released) != len(expected) {
+		t.Fatalf("Expected %d released tasks, got %d", len(expected), len(released))
+	}
+	for i, id := range expected {
+		if released[i] != id {
+			t.Errorf("Expected task %d at position %d, got %d", id, i, released[i])
+		}
+	}
+	if s.Len() != 0 {
+		t.Errorf("Expected empty scheduler, got %d tasks", s.Len())
+	}
+}
+
+func TestSchedulerRemove(t *testing.T) {
+	released := make(chan model.Task, 1)
+	s := New(func(task model.Task) {
+		released <- task
+	})
+	s.Start()
+	defer s.Stop()
+
+	runAt := time.Now().Add(30 * time.Millisecond)
+	s.Schedule(model.Task{ID: 1, RunAt: &runAt})
+
+	if !s.Remove(1) {
+		t.Fatal("Expected task to be removed")
+	}
+	if s.Remove(1) {
+		t.Error("Expected second removal to fail")
+	}
+
+	select {
+	case <-released:
+		t.Error("Removed task was released")
+	case <-time.After(60 * time.Millisecond):
+	}
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"MyFirstGoApp/internal/auth"
+	"MyFirstGoApp/internal/core"
+	"MyFirstGoApp/internal/model"
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/apikeys [post]
+// @OperationId issueAPIKey
+// @Param key body model.APIKey true "Name and scopes of the key"
+// @Summary Issue an API key
+// @Description Creates an API key with the scopes tasks:read, tasks:write, tasks:admin or deployment:admin for the tenant of the caller. Only a deployment admin may issue keys for another tenant, and no key may grant a scope the caller lacks. The key is returned only in this response.
+// @Accept json
+// @Produce json
+// @Success 201 {object} model.IssuedAPIKey
+// @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Caller may not issue the key"
+// @Failure 500 {string} string "Internal server error"
+func (h *Handlers) issueAPIKey(c *gin.Context) {
+	var request model.APIKey
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, ok := keyTenant(c, request.Tenant)
+	if !ok {
+		return
+	}
+	request.Tenant = tenant
+	principal, _ := auth.PrincipalFrom(c)
+	for _, scope := range request.Scopes {
+		// Unknown scopes are refused by the validation of the key.
+		if slices.Contains(model.Scopes, scope) && !principal.HasScope(scope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API key may not grant the " + scope + " scope"})
+			return
+		}
+	}
+	key, err := h.core.IssueAPIKey(request.Name, request.Tenant, request.Scopes)
+	if err != nil {
+		if errors.Is(err, core.ErrInvalidAPIKey) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/apikeys [get]
+// @OperationId getAPIKeys
+// @Param tenant query string false "Tenant of the keys, the tenant of the caller by default; other tenants need deployment:admin"
+// @Summary Get API keys
+// @Description Returns the issued API keys of the tenant, including revoked ones, without their secrets
+// @Produce json
+// @Success 200 {array} model.APIKey
+// @Failure 500 {string} string "Internal server error"
+func (h *Handlers) getAPIKeys(c *gin.Context) {
+	tenant, ok := keyTenant(c, c.Query("tenant"))
+	if !ok {
+		return
+	}
+	keys, err := h.core.GetAPIKeys(tenant)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/apikeys/{id} [delete]
+// @OperationId revokeAPIKey
+// @Param id path int true "API key ID"
+// @Param tenant query string false "Tenant of the key, the tenant of the caller by default; other tenants need deployment:admin"
+// @Summary Revoke an API key
+// @Description Revokes an API key of the tenant; requests with it are rejected from then on
+// @Success 204 "No Content"
+// @Failure 403 {string} string "Caller may not manage the keys of the tenant"
+// @Failure 404 {string} string "Active API key not found"
+// @Failure 500 {string} string "Internal server error"
+func (h *Handlers) revokeAPIKey(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID is not integer!"})
+		return
+	}
+
+	tenant, ok := keyTenant(c, c.Query("tenant"))
+	if !ok {
+		return
+	}
+	err = h.core.RevokeAPIKey(tenant, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Active API key not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// keyTenant returns the tenant whose API keys a request manages: the tenant
+// of the caller, or the requested one for a deployment admin. It answers 403
+// Forbidden and returns false when the caller may not manage the keys of the
+// requested tenant.
+func keyTenant(c *gin.Context, requested string) (string, bool) {
+	principal, _ := auth.PrincipalFrom(c)
+	if requested == "" || requested == principal.Tenant {
+		return principal.Tenant, true
+	}
+	if !principal.HasScope(model.ScopeDeploymentAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key may not manage the keys of tenant " + requested})
+		return "", false
+	}
+	return requested, true
+}
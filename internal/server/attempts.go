@@ -0,0 +1,43 @@
+package server
+
+import (
+	"MyFirstGoApp/internal/auth"
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Tags Tasks
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/tasks/{id}/attempts [get]
+// @OperationId getTaskAttempts
+// @Param id path int true "Task ID"
+// @Summary Get attempt history of a task
+// @Description Returns every execution of a task with the request sent, the response or error and the duration
+// @Produce json
+// @Success 200 {array} model.TaskAttempt
+// @Failure 404 {string} string "Task not found"
+// @Failure 500 {string} string "Internal server error"
+func (h *Handlers) getTaskAttempts(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID is not integer!"})
+		return
+	}
+
+	attempts, err := h.core.GetTaskAttempts(auth.TenantFrom(c), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
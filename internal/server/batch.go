@@ -0,0 +1,133 @@
+package server
+
+import (
+	"MyFirstGoApp/internal/auth"
+	"MyFirstGoApp/internal/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxBatchSize = 10000
+
+var errBatchTooLarge = fmt.Errorf("batch must not contain more than %d tasks", maxBatchSize)
+
+// @Tags Tasks
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/tasks/batch [post]
+// @OperationId createTasksBatch
+// @Param tasks body []model.Task true "JSON array of tasks, or one task per line with Content-Type application/x-ndjson"
+// @Summary Create a batch of tasks
+// @Description Validates every task, stores the valid ones in one transaction and queues them. The result of each task is reported by its position in the batch.
+// @Accept json
+// @Accept application/x-ndjson
+// @Produce json
+// @Success 200 {array} model.BatchResult
+// @Failure 400 {string} string "Bad request"
+// @Failure 413 {string} string "Batch is too large"
+// @Failure 500 {string} string "Internal server error"
+// @Failure 429 {string} string "Queue-depth quota of the tenant exceeded"
+// @Failure 503 {string} string "Service is shutting down"
+func (h *Handlers) createTasksBatch(c *gin.Context) {
+	var items []json.RawMessage
+	var err error
+	if strings.HasPrefix(c.ContentType(), "application/x-ndjson") {
+		items, err = readNDJSON(c.Request.Body)
+	} else {
+		items, err = readJSONArray(c.Request.Body)
+	}
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errBatchTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	results := make([]model.BatchResult, len(items))
+	var tasks []model.Task
+	var positions []int
+	for i, item := range items {
+		results[i].Index = i
+		var task model.Task
+		if err := json.Unmarshal(item, &task); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		task.ResetServerFields()
+		task.CreatedBy = auth.SubjectFrom(c)
+		task.Tenant = auth.TenantFrom(c)
+		if err := task.Validate(); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		tasks = append(tasks, task)
+		positions = append(positions, i)
+	}
+
+	if len(tasks) > 0 {
+		ids, err := h.core.CreateTasks(tasks)
+		if err != nil {
+			c.JSON(creationErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		for i, id := range ids {
+			results[positions[i]].ID = id
+		}
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+func readJSONArray(r io.Reader) ([]json.RawMessage, error) {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("request body must be a JSON array of tasks")
+	}
+
+	var items []json.RawMessage
+	for decoder.More() {
+		if len(items) == maxBatchSize {
+			return nil, errBatchTooLarge
+		}
+		var item json.RawMessage
+		if err := decoder.Decode(&item); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func readNDJSON(r io.Reader) ([]json.RawMessage, error) {
+	decoder := json.NewDecoder(r)
+	var items []json.RawMessage
+	for {
+		var item json.RawMessage
+		err := decoder.Decode(&item)
+		if err == io.EOF {
+			return items, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", len(items)+1, err)
+		}
+		if len(items) == maxBatchSize {
+			return nil, errBatchTooLarge
+		}
+		items = append(items, item)
+	}
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadJSONArray(t *testing.T) {
+	items, err := readJSONArray(strings.NewReader(`[{"method":"GET"}, {"method":"POST"}, 42]`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(items))
+	}
+	if string(items[1]) != `{"method":"POST"}` {
+		t.Errorf("Unexpected item %s", items[1])
+	}
+
+	if _, err := readJSONArray(strings.NewReader(`{"method":"GET"}`)); err == nil {
+		t.Error("Expected error for a non-array body, got nil")
+	}
+}
+
+func TestReadNDJSON(t *testing.T) {
+	body := "{\"method\":\"GET\"}\n{\"method\":\"POST\"}\n\n{\"method\":\"PUT\"}\n"
+	items, err := readNDJSON(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(items))
+	}
+
+	if _, err := readNDJSON(strings.NewReader("{\"method\":\"GET\"}\n{broken\n")); err == nil {
+		t.Error("Expected error for a malformed line, got nil")
+	}
+}
+
+func TestReadBatchTooLarge(t *testing.T) {
+	body := "[" + strings.Repeat("{},", maxBatchSize) + "{}]"
+	if _, err := readJSONArray(strings.NewReader(body)); !errors.Is(err, errBatchTooLarge) {
+		t.Errorf("Expected errBatchTooLarge, got %v", err)
+	}
+}
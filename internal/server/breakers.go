@@ -0,0 +1,55 @@
+package server
+
+import (
+	"MyFirstGoApp/internal/HTTPclient"
+	"MyFirstGoApp/internal/postgres"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newBreakers builds the circuit breakers of the destination hosts from
+// BREAKER_FAILURE_THRESHOLD, BREAKER_OPEN_TIMEOUT, BREAKER_HALF_OPEN_REQUESTS
+// and BREAKER_OPEN_ACTION.
+func newBreakers() *HTTPclient.Breakers {
+	config := HTTPclient.DefaultBreakerConfig
+	config.FailureThreshold = positiveEnv("BREAKER_FAILURE_THRESHOLD", config.FailureThreshold)
+	config.HalfOpenRequests = positiveEnv("BREAKER_HALF_OPEN_REQUESTS", config.HalfOpenRequests)
+
+	var err error
+	config.OpenTimeout, err = time.ParseDuration(postgres.GetEnv("BREAKER_OPEN_TIMEOUT", config.OpenTimeout.String()))
+	if err != nil {
+		log.Fatal("Invalid BREAKER_OPEN_TIMEOUT: ", err)
+	}
+	config.OpenAction = postgres.GetEnv("BREAKER_OPEN_ACTION", config.OpenAction)
+	if config.OpenAction != HTTPclient.OpenDefer && config.OpenAction != HTTPclient.OpenFail {
+		log.Fatalf("Unknown BREAKER_OPEN_ACTION %q", config.OpenAction)
+	}
+	return HTTPclient.NewBreakers(config)
+}
+
+// positiveEnv reads a positive integer from the environment variable key.
+func positiveEnv(key string, defaultValue int) int {
+	value := postgres.GetEnv(key, strconv.Itoa(defaultValue))
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 {
+		log.Fatalf("Invalid %s %q, expected a positive integer", key, value)
+	}
+	return number
+}
+
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/breakers [get]
+// @OperationId getBreakers
+// @Summary Get circuit breakers
+// @Description Returns the circuit breakers of the destination hosts that have failed recently
+// @Produce json
+// @Success 200 {array} model.BreakerState
+func (h *Handlers) getBreakers(c *gin.Context) {
+	c.JSON(http.StatusOK, h.core.GetBreakerStates())
+}
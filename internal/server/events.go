@@ -0,0 +1,106 @@
+package server
+
+import (
+	"MyFirstGoApp/internal/auth"
+	"MyFirstGoApp/internal/events"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sseHeartbeat is how often an idle event stream sends a comment, so that
+// proxies do not close it.
+const sseHeartbeat = 15 * time.Second
+
+// @Tags Tasks
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/tasks/events [get]
+// @OperationId streamTaskEvents
+// @Param id query []int false "IDs of the tasks to watch" collectionFormat(multi)
+// @Param status query string false "Comma-separated statuses to include"
+// @Param tag query []string false "Tags the tasks must all have" collectionFormat(multi)
+// @Param Last-Event-ID header string false "ID of the last event received, to resume after a reconnect"
+// @Summary Stream task events
+// @Description Streams Server-Sent Events: a "status" event every time a task changes its status and a "response" event when its response is stored. The data of an event is the task. A client that reconnects with Last-Event-ID gets the events it missed, as far as they are still buffered. After a restart of the service it gets a "resync" event instead, and has to reload the tasks.
+// @Produce text/event-stream
+// @Success 200 {object} model.Task
+// @Failure 400 {string} string "Bad request"
+func (h *Handlers) streamTaskEvents(c *gin.Context) {
+	filter, err := parseEventFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// An ID the broker does not know, such as one from before a restart,
+	// resyncs the client rather than failing its reconnect.
+	sub := h.core.SubscribeEvents(auth.TenantFrom(c), filter, c.GetHeader("Last-Event-ID"))
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Shutdown, or the client fell too far behind and has to
+				// reconnect.
+				return
+			}
+			if err := writeEvent(c.Writer, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeEvent(w io.Writer, event events.Event) error {
+	data := []byte("{}")
+	if event.Type != events.Resync {
+		var err error
+		if data, err = json.Marshal(event.Task); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.StreamID(), event.Type, data)
+	return err
+}
+
+func parseEventFilter(c *gin.Context) (events.Filter, error) {
+	var filter events.Filter
+	for _, value := range c.QueryArray("id") {
+		for _, idStr := range strings.Split(value, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(idStr), 10, 64)
+			if err != nil {
+				return filter, fmt.Errorf("ID %q is not integer", idStr)
+			}
+			filter.IDs = append(filter.IDs, id)
+		}
+	}
+	var err error
+	if filter.Statuses, err = parseStatuses(c); err != nil {
+		return filter, err
+	}
+	filter.Tags = c.QueryArray("tag")
+	return filter, nil
+}
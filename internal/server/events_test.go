@@ -0,0 +1,75 @@
+package server
+
+import (
+	"MyFirstGoApp/internal/core"
+	"MyFirstGoApp/internal/events"
+	"MyFirstGoApp/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestStreamTaskEventsResumes(t *testing.T) {
+	broker := events.NewBroker(10)
+	sub := broker.Subscribe(events.Filter{}, "")
+	broker.Publish(events.StatusChanged, model.Task{ID: 1, Status: model.New})
+	first := <-sub.Events()
+	broker.Publish(events.StatusChanged, model.Task{ID: 1, Status: model.In_process})
+	broker.Publish(events.StatusChanged, model.Task{ID: 2, Status: model.In_process})
+	broker.Publish(events.ResponseStored, model.Task{ID: 1, Status: model.Done})
+	// A closed broker replays the missed events and ends the stream.
+	broker.Close()
+
+	handlers := NewHandlers(core.NewApp(nil, core.WithEvents(broker)))
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/tasks/:id", handlers.getTaskById)
+	router.GET("/api/v1/tasks/events", handlers.streamTaskEvents)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/v1/tasks/events?id=1", nil)
+	req.Header.Set("Last-Event-ID", first.StreamID())
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("Expected Content-Type text/event-stream, got %q", contentType)
+	}
+	body := w.Body.String()
+	epoch := first.Epoch
+	if strings.Contains(body, "id: "+epoch+"-1\n") || strings.Contains(body, "id: "+epoch+"-3\n") {
+		t.Errorf("Expected only the missed events of task 1, got\n%s", body)
+	}
+	if !strings.Contains(body, "id: "+epoch+"-2\nevent: status\ndata: {") ||
+		!strings.Contains(body, "id: "+epoch+"-4\nevent: response\ndata: {") {
+		t.Errorf("Expected events 2 and 4, got\n%s", body)
+	}
+
+	// An event ID from before a restart gets a resync.
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/api/v1/tasks/events?id=1", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	router.ServeHTTP(w, req)
+	if expected := "id: " + epoch + "-4\nevent: resync\ndata: {}\n\n"; w.Body.String() != expected {
+		t.Errorf("Expected only a resync event, got\n%s", w.Body.String())
+	}
+}
+
+func TestStreamTaskEventsBadFilter(t *testing.T) {
+	handlers := NewHandlers(core.NewApp(nil))
+	router := gin.New()
+	router.GET("/api/v1/tasks/events", handlers.streamTaskEvents)
+
+	for _, target := range []string{"/api/v1/tasks/events?id=x", "/api/v1/tasks/events?status=unknown"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for %s, got %d", http.StatusBadRequest, target, w.Code)
+		}
+	}
+}
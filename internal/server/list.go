@@ -0,0 +1,172 @@
+package server
+
+import (
+	"MyFirstGoApp/internal/auth"
+	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/storage"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var taskStatuses = []string{model.New, model.Scheduled, model.In_process, model.Retrying, model.Done, model.Error, model.Cancelled}
+
+// @Tags Tasks
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/tasks [get]
+// @OperationId getTasks
+// @Param status query string false "Comma-separated statuses to include"
+// @Param method query string false "HTTP method"
+// @Param host query string false "Host of the task URL"
+// @Param url_prefix query string false "Prefix of the task URL"
+// @Param tag query []string false "Tags the tasks must all have" collectionFormat(multi)
+// @Param created_after query string false "Include tasks created at or after this RFC 3339 time"
+// @Param created_before query string false "Include tasks created before this RFC 3339 time"
+// @Param sort query string false "Sort order: id, created_at or priority, prefixed with - for descending" default(id)
+// @Param limit query int false "Page size, at most 1000" default(100)
+// @Param cursor query string false "X-Next-Cursor of the previous page"
+// @Summary Get tasks
+// @Description Returns one page of tasks. The X-Next-Cursor header holds the cursor of the next page and is missing on the last one.
+// @Produce json
+// @Success 200 {array} model.Task
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+func (h *Handlers) getTasks(c *gin.Context) {
+	query, err := parseTaskQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.Tenant = auth.TenantFrom(c)
+
+	tasks, next, err := h.core.ListTasks(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if next != nil {
+		c.Header("X-Next-Cursor", encodeCursor(query, *next))
+	}
+	if tasks == nil {
+		tasks = []model.Task{}
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// parseTaskQuery reads the filters, the sort order and the page of the task
+// list from the query string.
+func parseTaskQuery(c *gin.Context) (storage.TaskQuery, error) {
+	var query storage.TaskQuery
+	var err error
+	if query.Statuses, err = parseStatuses(c); err != nil {
+		return query, err
+	}
+	query.Method = c.Query("method")
+	query.Host = c.Query("host")
+	query.URLPrefix = c.Query("url_prefix")
+	query.Tags = c.QueryArray("tag")
+
+	if query.CreatedAfter, err = parseTimeParam(c, "created_after"); err != nil {
+		return query, err
+	}
+	if query.CreatedBefore, err = parseTimeParam(c, "created_before"); err != nil {
+		return query, err
+	}
+
+	sort := c.DefaultQuery("sort", storage.SortByID)
+	query.Descending = strings.HasPrefix(sort, "-")
+	query.SortBy = strings.TrimPrefix(sort, "-")
+	switch query.SortBy {
+	case storage.SortByID, storage.SortByCreatedAt, storage.SortByPriority:
+	default:
+		return query, fmt.Errorf("unknown sort order %q", sort)
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit < 1 {
+			return query, errors.New("limit must be a positive integer")
+		}
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		query.After, err = decodeCursor(query, cursor)
+		if err != nil {
+			return query, err
+		}
+	}
+	return query, nil
+}
+
+// parseStatuses reads the status parameter, a comma-separated list that may
+// also be repeated.
+func parseStatuses(c *gin.Context) ([]string, error) {
+	var statuses []string
+	for _, value := range c.QueryArray("status") {
+		for _, status := range strings.Split(value, ",") {
+			status = strings.TrimSpace(status)
+			if !slices.Contains(taskStatuses, status) {
+				return nil, fmt.Errorf("unknown status %q", status)
+			}
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
+
+func parseTimeParam(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 time: %w", name, err)
+	}
+	return &t, nil
+}
+
+// pageCursor is the content of an opaque page cursor. It carries the sort
+// order it was made for, so that it is not applied to a different one.
+type pageCursor struct {
+	Sort string `json:"sort"`
+	storage.TaskCursor
+}
+
+func sortKey(query storage.TaskQuery) string {
+	if query.Descending {
+		return "-" + query.SortBy
+	}
+	return query.SortBy
+}
+
+func encodeCursor(query storage.TaskQuery, cursor storage.TaskCursor) string {
+	data, _ := json.Marshal(pageCursor{Sort: sortKey(query), TaskCursor: cursor})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(query storage.TaskQuery, value string) (*storage.TaskCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	if cursor.Sort != sortKey(query) {
+		return nil, fmt.Errorf("cursor was made for sort order %q", cursor.Sort)
+	}
+	return &cursor.TaskCursor, nil
+}
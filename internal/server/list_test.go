@@ -0,0 +1,67 @@
+package server
+
+import (
+	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/storage"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func queryContext(rawQuery string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/api/v1/tasks?"+rawQuery, nil)
+	return c
+}
+
+func TestParseTaskQuery(t *testing.T) {
+	query, err := parseTaskQuery(queryContext("status=new,error&status=done&method=post&host=example.com" +
+		"&url_prefix=https://example.com/api&created_after=2026-01-01T00:00:00Z&sort=-created_at&limit=50"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(query.Statuses) != 3 || query.Statuses[0] != model.New || query.Statuses[2] != model.Done {
+		t.Errorf("Unexpected statuses %v", query.Statuses)
+	}
+	if query.Method != "post" || query.Host != "example.com" || query.URLPrefix != "https://example.com/api" {
+		t.Errorf("Unexpected filters %+v", query)
+	}
+	if query.CreatedAfter == nil || !query.CreatedAfter.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected created_after %v", query.CreatedAfter)
+	}
+	if query.CreatedBefore != nil {
+		t.Errorf("Expected no created_before, got %v", query.CreatedBefore)
+	}
+	if query.SortBy != storage.SortByCreatedAt || !query.Descending {
+		t.Errorf("Expected descending created_at, got %q descending %v", query.SortBy, query.Descending)
+	}
+	if query.Limit != 50 {
+		t.Errorf("Expected limit 50, got %d", query.Limit)
+	}
+
+	for _, rawQuery := range []string{"status=unknown", "sort=url", "limit=0", "limit=x", "created_before=yesterday", "cursor=not-a-cursor!"} {
+		if _, err := parseTaskQuery(queryContext(rawQuery)); err == nil {
+			t.Errorf("Expected error for %q, got nil", rawQuery)
+		}
+	}
+}
+
+func TestPageCursor(t *testing.T) {
+	query := storage.TaskQuery{SortBy: storage.SortByCreatedAt, Descending: true}
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)
+	cursor := encodeCursor(query, storage.TaskCursor{ID: 42, CreatedAt: createdAt})
+
+	parsed, err := parseTaskQuery(queryContext("sort=-created_at&cursor=" + cursor))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if parsed.After == nil || parsed.After.ID != 42 || !parsed.After.CreatedAt.Equal(createdAt) {
+		t.Errorf("Unexpected cursor %+v", parsed.After)
+	}
+
+	if _, err := parseTaskQuery(queryContext("sort=created_at&cursor=" + cursor)); err == nil {
+		t.Error("Expected error for a cursor of another sort order, got nil")
+	}
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"MyFirstGoApp/internal/core"
+	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/postgres"
+	"MyFirstGoApp/internal/quota"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newQuotas builds the tenant quotas. TENANT_MAX_QUEUED and
+// TENANT_WORKER_SHARE set the quota of every tenant, and TENANT_QUOTAS, a JSON
+// array of quotas, the quotas of single tenants. The quotas can be changed at
+// runtime through the admin endpoints.
+func newQuotas() *quota.Quotas {
+	var quotas []model.TenantQuota
+	if config := postgres.GetEnv("TENANT_QUOTAS", ""); config != "" {
+		if err := json.Unmarshal([]byte(config), &quotas); err != nil {
+			log.Fatal("Invalid TENANT_QUOTAS: ", err)
+		}
+	}
+
+	defaults := model.TenantQuota{Tenant: quota.AnyTenant}
+	var err error
+	defaults.MaxQueued, err = strconv.Atoi(postgres.GetEnv("TENANT_MAX_QUEUED", "0"))
+	if err != nil {
+		log.Fatal("Invalid TENANT_MAX_QUEUED: ", err)
+	}
+	defaults.WorkerShare, err = strconv.ParseFloat(postgres.GetEnv("TENANT_WORKER_SHARE", "0"), 64)
+	if err != nil {
+		log.Fatal("Invalid TENANT_WORKER_SHARE: ", err)
+	}
+	if defaults.MaxQueued != 0 || defaults.WorkerShare != 0 {
+		quotas = append([]model.TenantQuota{defaults}, quotas...)
+	}
+
+	for _, tenantQuota := range quotas {
+		if err := tenantQuota.Validate(); err != nil {
+			log.Fatalf("Invalid quota of tenant %q: %v", tenantQuota.Tenant, err)
+		}
+	}
+	return quota.New(quotas)
+}
+
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/quotas [get]
+// @OperationId getQuotas
+// @Summary Get tenant quotas
+// @Description Returns the queue-depth and worker-share quotas of the tenants; the * quota applies to the tenants without one of their own
+// @Produce json
+// @Success 200 {array} model.TenantQuota
+func (h *Handlers) getQuotas(c *gin.Context) {
+	c.JSON(http.StatusOK, h.core.GetQuotas())
+}
+
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/quotas/{tenant} [put]
+// @OperationId setQuota
+// @Param tenant path string true "Tenant, or * for the tenants without a quota of their own"
+// @Param quota body model.TenantQuota true "Quota, its tenant is taken from the path"
+// @Summary Set a tenant quota
+// @Description Adds or replaces the quota of the tenant
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.TenantQuota
+// @Failure 400 {string} string "Bad request"
+func (h *Handlers) setQuota(c *gin.Context) {
+	var tenantQuota model.TenantQuota
+	if err := c.ShouldBindJSON(&tenantQuota); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tenantQuota.Tenant = c.Param("tenant")
+
+	err := h.core.SetQuota(tenantQuota)
+	if err != nil {
+		if errors.Is(err, core.ErrInvalidQuota) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tenantQuota)
+}
+
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/quotas/{tenant} [delete]
+// @OperationId deleteQuota
+// @Param tenant path string true "Tenant, or *"
+// @Summary Delete a tenant quota
+// @Description Removes the quota of the tenant, which then gets the * quota
+// @Success 204 "No Content"
+// @Failure 404 {string} string "Quota not found"
+func (h *Handlers) deleteQuota(c *gin.Context) {
+	if !h.core.DeleteQuota(c.Param("tenant")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quota not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"MyFirstGoApp/internal/core"
+	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/postgres"
+	"MyFirstGoApp/internal/ratelimit"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newRateLimiter builds the limiter of outbound requests from RATE_LIMITS, a
+// JSON array of rate limits. The limits can be changed at runtime through the
+// admin endpoints.
+func newRateLimiter() *ratelimit.Limiter {
+	var rules []model.RateLimit
+	if config := postgres.GetEnv("RATE_LIMITS", ""); config != "" {
+		if err := json.Unmarshal([]byte(config), &rules); err != nil {
+			log.Fatal("Invalid RATE_LIMITS: ", err)
+		}
+	}
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			log.Fatalf("Invalid RATE_LIMITS rule %q: %v", rule.Pattern, err)
+		}
+	}
+	return ratelimit.New(rules)
+}
+
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/ratelimits [get]
+// @OperationId getRateLimits
+// @Summary Get outbound rate limits
+// @Description Returns the rate limits of outbound requests per destination host
+// @Produce json
+// @Success 200 {array} model.RateLimit
+func (h *Handlers) getRateLimits(c *gin.Context) {
+	c.JSON(http.StatusOK, h.core.GetRateLimits())
+}
+
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/ratelimits/{pattern} [put]
+// @OperationId setRateLimit
+// @Param pattern path string true "Host, *.domain or *"
+// @Param limit body model.RateLimit true "Rate limit, its pattern is taken from the path"
+// @Summary Set an outbound rate limit
+// @Description Adds or replaces the rate limit of the hosts matching the pattern; waiting requests pick up the new limit
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.RateLimit
+// @Failure 400 {string} string "Bad request"
+func (h *Handlers) setRateLimit(c *gin.Context) {
+	var rule model.RateLimit
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule.Pattern = c.Param("pattern")
+
+	err := h.core.SetRateLimit(rule)
+	if err != nil {
+		if errors.Is(err, core.ErrInvalidRateLimit) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/ratelimits/{pattern} [delete]
+// @OperationId deleteRateLimit
+// @Param pattern path string true "Host, *.domain or *"
+// @Summary Delete an outbound rate limit
+// @Description Removes the rate limit with the pattern
+// @Success 204 "No Content"
+// @Failure 404 {string} string "Rate limit not found"
+func (h *Handlers) deleteRateLimit(c *gin.Context) {
+	if !h.core.DeleteRateLimit(c.Param("pattern")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rate limit not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,269 @@
+package server
+
+import (
+	"MyFirstGoApp/internal/auth"
+	"MyFirstGoApp/internal/core"
+	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/storage"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeStorage answers the storage calls of the routes under test; the other
+// methods of storage.Storage are left unimplemented.
+type fakeStorage struct {
+	storage.Storage
+	keys      []model.APIKey
+	tasks     []model.Task
+	schedules []model.Schedule
+}
+
+func (s *fakeStorage) GetAPIKeyByHash(hash string) (model.APIKey, error) {
+	for _, key := range s.keys {
+		if key.Hash == hash {
+			return key, nil
+		}
+	}
+	return model.APIKey{}, sql.ErrNoRows
+}
+
+func (s *fakeStorage) AddAPIKey(key model.APIKey) (int64, error) {
+	s.keys = append(s.keys, key)
+	return int64(len(s.keys)), nil
+}
+
+func (s *fakeStorage) GetAllAPIKeys(tenant string) ([]model.APIKey, error) {
+	keys := []model.APIKey{}
+	for _, key := range s.keys {
+		if key.Tenant == tenant {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (s *fakeStorage) RevokeAPIKey(tenant string, id int64, revokedAt time.Time) error {
+	for i, key := range s.keys {
+		if key.ID == id && key.Tenant == tenant && key.RevokedAt == nil {
+			s.keys[i].RevokedAt = &revokedAt
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (s *fakeStorage) AddTasks(tasks []model.Task) ([]int64, error) {
+	ids := make([]int64, len(tasks))
+	for i, task := range tasks {
+		s.tasks = append(s.tasks, task)
+		ids[i] = int64(len(s.tasks))
+	}
+	return ids, nil
+}
+
+func (s *fakeStorage) AddSchedule(schedule model.Schedule) (int64, error) {
+	s.schedules = append(s.schedules, schedule)
+	return int64(len(s.schedules)), nil
+}
+
+func (s *fakeStorage) GetAllSchedules(tenant string) ([]model.Schedule, error) {
+	return []model.Schedule{}, nil
+}
+
+func (s *fakeStorage) DeleteScheduleByID(tenant string, id int64) error {
+	return nil
+}
+
+func (s *fakeStorage) CleanStorage(tenant string) error {
+	return nil
+}
+
+func testRouter(keys ...model.APIKey) *gin.Engine {
+	router, _ := testRouterWithStorage(keys...)
+	return router
+}
+
+func testRouterWithStorage(keys ...model.APIKey) (*gin.Engine, *fakeStorage) {
+	gin.SetMode(gin.TestMode)
+	for i := range keys {
+		keys[i].ID = int64(i + 1)
+		keys[i].Hash = auth.HashKey(keys[i].Name)
+	}
+	store := &fakeStorage{keys: keys}
+	return newRouter(core.NewApp(store), nil), store
+}
+
+func TestRouterScopes(t *testing.T) {
+	router := testRouter(
+		model.APIKey{Name: "reader", Tenant: "team-a", Scopes: []string{model.ScopeTasksRead}},
+		model.APIKey{Name: "writer", Tenant: "team-a", Scopes: []string{model.ScopeTasksWrite}},
+		model.APIKey{Name: "admin", Tenant: "team-a", Scopes: []string{model.ScopeTasksAdmin}},
+		model.APIKey{Name: "operator", Tenant: model.DefaultTenant, Scopes: []string{model.ScopeDeploymentAdmin}},
+	)
+
+	tests := []struct {
+		method string
+		target string
+		scope  string
+		status int
+	}{
+		{"GET", "/api/v1/schedules", model.ScopeTasksRead, http.StatusOK},
+		{"DELETE", "/api/v1/schedules/1", model.ScopeTasksWrite, http.StatusNoContent},
+		{"DELETE", "/api/v1/tasks", model.ScopeTasksAdmin, http.StatusNoContent},
+		{"GET", "/api/v1/admin/apikeys", model.ScopeTasksAdmin, http.StatusOK},
+		{"GET", "/api/v1/admin/ratelimits", model.ScopeDeploymentAdmin, http.StatusOK},
+		{"GET", "/api/v1/admin/quotas", model.ScopeDeploymentAdmin, http.StatusOK},
+	}
+	scopes := map[string][]string{
+		"reader":   {model.ScopeTasksRead},
+		"writer":   {model.ScopeTasksWrite},
+		"admin":    {model.ScopeTasksRead, model.ScopeTasksWrite, model.ScopeTasksAdmin},
+		"operator": model.Scopes,
+	}
+	for _, tt := range tests {
+		for _, credential := range []string{"", "unknown", "reader", "writer", "admin", "operator"} {
+			expected := http.StatusUnauthorized
+			if granted, ok := scopes[credential]; ok {
+				expected = http.StatusForbidden
+				if slices.Contains(granted, tt.scope) {
+					expected = tt.status
+				}
+			}
+
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if credential != "" {
+				req.Header.Set("X-API-Key", credential)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != expected {
+				t.Errorf("%s %s with key %q: expected status code %d, got %d",
+					tt.method, tt.target, credential, expected, w.Code)
+			}
+		}
+	}
+}
+
+func TestRouterAPIKeyTenants(t *testing.T) {
+	router := testRouter(
+		model.APIKey{Name: "admin-a", Tenant: "team-a", Scopes: []string{model.ScopeTasksAdmin}},
+		model.APIKey{Name: "admin-b", Tenant: "team-b", Scopes: []string{model.ScopeTasksAdmin}},
+		model.APIKey{Name: "operator", Tenant: model.DefaultTenant, Scopes: []string{model.ScopeDeploymentAdmin}},
+	)
+	send := func(credential string, method string, target string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("X-API-Key", credential)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name       string
+		credential string
+		method     string
+		target     string
+		body       string
+		status     int
+	}{
+		{"Other tenant's key", "admin-a", "DELETE", "/api/v1/admin/apikeys/2", "", http.StatusNotFound},
+		{"Other tenant's keys", "admin-a", "GET", "/api/v1/admin/apikeys?tenant=team-b", "", http.StatusForbidden},
+		{"Other tenant's key by tenant", "admin-a", "DELETE", "/api/v1/admin/apikeys/2?tenant=team-b", "", http.StatusForbidden},
+		{"Key for other tenant", "admin-a", "POST", "/api/v1/admin/apikeys",
+			`{"name":"b","tenant":"team-b","scopes":["tasks:read"]}`, http.StatusForbidden},
+		{"Key with wider scope", "admin-a", "POST", "/api/v1/admin/apikeys",
+			`{"name":"a","scopes":["deployment:admin"]}`, http.StatusForbidden},
+		{"Key for own tenant", "admin-a", "POST", "/api/v1/admin/apikeys",
+			`{"name":"a","tenant":"team-a","scopes":["tasks:read"]}`, http.StatusCreated},
+		{"Operator issues for tenant", "operator", "POST", "/api/v1/admin/apikeys",
+			`{"name":"b","tenant":"team-b","scopes":["tasks:admin"]}`, http.StatusCreated},
+		{"Operator lists tenant", "operator", "GET", "/api/v1/admin/apikeys?tenant=team-b", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := send(tt.credential, tt.method, tt.target, tt.body); w.Code != tt.status {
+				t.Errorf("Expected status code %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	var keys []model.APIKey
+	w := send("admin-a", "GET", "/api/v1/admin/apikeys", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &keys); err != nil {
+		t.Fatalf("Expected a key list, got %s", w.Body.String())
+	}
+	for _, key := range keys {
+		if key.Tenant != "team-a" {
+			t.Errorf("Expected only the keys of team-a, got %+v", key)
+		}
+	}
+	if w := send("admin-b", "GET", "/api/v1/admin/apikeys", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected the key of team-b to stay active, got status code %d", w.Code)
+	}
+}
+
+func TestRouterIgnoresServerFields(t *testing.T) {
+	router, store := testRouterWithStorage(
+		model.APIKey{Name: "writer", Tenant: "team-a", Scopes: []string{model.ScopeTasksWrite}},
+	)
+	send := func(target string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", target, strings.NewReader(body))
+		req.Header.Set("X-API-Key", "writer")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	serverFields := `"method":"GET","url":"http://example.com","status":"Done","attempts":3,` +
+		`"idempotency_key":"same","tenant":"team-b","created_by":"someone","schedule_id":7`
+
+	w := send("/api/v1/tasks/batch", `[{`+serverFields+`},{`+serverFields+`}]`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d for the batch, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if len(store.tasks) != 2 {
+		t.Fatalf("Expected 2 stored tasks, got %d", len(store.tasks))
+	}
+	for _, task := range store.tasks {
+		if task.IdempotencyKey != "" || task.Tenant != "team-a" || task.CreatedBy != "apikey:1" ||
+			task.Attempts != 0 || task.ScheduleID != nil || task.Status != model.New {
+			t.Errorf("Expected the server-set fields of a batch task to be ignored, got %+v", task)
+		}
+	}
+
+	w = send("/api/v1/schedules", `{"name":"hourly","cron":"0 * * * *","task":{`+serverFields+`}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d for the schedule, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if len(store.schedules) != 1 {
+		t.Fatalf("Expected 1 stored schedule, got %d", len(store.schedules))
+	}
+	task := store.schedules[0].Task
+	if task.IdempotencyKey != "" || task.Tenant != "" || task.CreatedBy != "apikey:1" ||
+		task.Attempts != 0 || task.ScheduleID != nil || task.Status != "" {
+		t.Errorf("Expected the server-set fields of a schedule template to be ignored, got %+v", task)
+	}
+}
+
+func TestRouterRejectsInvalidJSONPath(t *testing.T) {
+	router := testRouter(
+		model.APIKey{Name: "writer", Tenant: "team-a", Scopes: []string{model.ScopeTasksWrite}},
+	)
+	for _, path := range []string{"$.", "$[x]", "$[1", "$a"} {
+		body := `{"method":"GET","url":"http://example.com","assertions":{"json_path":[{"path":"` + path + `","equals":1}]}}`
+		req := httptest.NewRequest("POST", "/api/v1/tasks", strings.NewReader(body))
+		req.Header.Set("X-API-Key", "writer")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for path %q, got %d", http.StatusBadRequest, path, w.Code)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"MyFirstGoApp/internal/auth"
+	"MyFirstGoApp/internal/core"
+	"MyFirstGoApp/internal/model"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Tags Schedules
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/schedules [post]
+// @OperationId createSchedule
+// @Param schedule body model.Schedule true "Schedule object"
+// @Summary Create a recurring schedule
+// @Description Creates a schedule that creates a task from its template on every cron firing
+// @Accept json
+// @Produce json
+// @Success 201 {object} map[string]int64
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+func (h *Handlers) createSchedule(c *gin.Context) {
+	schedule := model.Schedule{Enabled: true}
+	if err := c.ShouldBindJSON(&schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	schedule.Tenant = auth.TenantFrom(c)
+	schedule.Task.ResetServerFields()
+	// The tasks of the schedule are recorded as created by its author.
+	schedule.Task.CreatedBy = auth.SubjectFrom(c)
+
+	id, err := h.core.CreateSchedule(schedule)
+	if err != nil {
+		if errors.Is(err, core.ErrInvalidSchedule) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"id": id,
+	})
+}
+
+// @Tags Schedules
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/schedules [get]
+// @OperationId getSchedules
+// @Summary Get all schedules
+// @Description Returns list of all recurring schedules
+// @Produce json
+// @Success 200 {array} model.Schedule
+// @Failure 500 {string} string "Internal server error"
+func (h *Handlers) getSchedules(c *gin.Context) {
+	schedules, err := h.core.GetAllSchedules(auth.TenantFrom(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+// @Tags Schedules
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/schedules/{id} [get]
+// @OperationId getScheduleById
+// @Param id path int true "Schedule ID"
+// @Summary Get schedule by ID
+// @Description Returns single schedule by ID
+// @Produce json
+// @Success 200 {object} model.Schedule
+// @Failure 404 {string} string "Schedule not found"
+// @Failure 500 {string} string "Internal server error"
+func (h *Handlers) getScheduleById(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID is not integer!"})
+		return
+	}
+
+	schedule, err := h.core.GetScheduleByID(auth.TenantFrom(c), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// @Tags Schedules
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/schedules/{id} [put]
+// @OperationId updateSchedule
+// @Param id path int true "Schedule ID"
+// @Param schedule body model.Schedule true "Schedule object"
+// @Summary Update schedule
+// @Description Replaces the cron expression, time zone, task template and enabled flag of a schedule
+// @Accept json
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Schedule not found"
+// @Failure 500 {string} string "Internal server error"
+func (h *Handlers) updateSchedule(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID is not integer!"})
+		return
+	}
+
+	schedule := model.Schedule{Enabled: true}
+	if err := c.ShouldBindJSON(&schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	schedule.ID = id
+	schedule.Tenant = auth.TenantFrom(c)
+	schedule.Task.ResetServerFields()
+	schedule.Task.CreatedBy = auth.SubjectFrom(c)
+
+	err = h.core.UpdateSchedule(schedule)
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, core.ErrInvalidSchedule):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// @Tags Schedules
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/schedules/{id} [delete]
+// @OperationId deleteScheduleById
+// @Param id path int true "Schedule ID"
+// @Summary Delete schedule
+// @Description Deletes schedule by ID, the tasks it created are kept
+// @Success 204 "No Content"
+// @Failure 404 {string} string "Schedule not found"
+// @Failure 500 {string} string "Internal server error"
+func (h *Handlers) deleteScheduleById(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID is not integer!"})
+		return
+	}
+
+	err = h.core.DeleteScheduleByID(auth.TenantFrom(c), id)
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// @Tags Schedules
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/schedules/{id}/tasks [get]
+// @OperationId getScheduleTasks
+// @Param id path int true "Schedule ID"
+// @Summary Get tasks of a schedule
+// @Description Returns the tasks created by the schedule
+// @Produce json
+// @Success 200 {array} model.Task
+// @Failure 404 {string} string "Schedule not found"
+// @Failure 500 {string} string "Internal server error"
+func (h *Handlers) getScheduleTasks(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID is not integer!"})
+		return
+	}
+
+	tasks, err := h.core.GetScheduleTasks(auth.TenantFrom(c), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
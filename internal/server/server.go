@@ -1,14 +1,22 @@
 package server
 
 import (
+	"MyFirstGoApp/internal/auth"
 	"MyFirstGoApp/internal/core"
 	"MyFirstGoApp/internal/model"
 	"MyFirstGoApp/internal/postgres"
+	"MyFirstGoApp/internal/queue"
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
 
 	_ "MyFirstGoApp/docs"
 
@@ -17,6 +25,9 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
+// maxIdempotencyKeyLength bounds the Idempotency-Key header.
+const maxIdempotencyKeyLength = 255
+
 type Handlers struct {
 	core *core.App
 }
@@ -28,27 +39,180 @@ func NewHandlers(app *core.App) *Handlers {
 }
 
 func ServerRun() {
-	storage, err := postgres.NewPostgreSQLStorage()
+	db, err := postgres.OpenDB()
 	if err != nil {
 		log.Fatal(err)
 	}
-	app := core.NewApp(storage)
+	storage := postgres.NewStorage(db)
+	leaseTimeout := leaseTimeout()
+	app := core.NewApp(storage,
+		core.WithQueue(newQueue(db, leaseTimeout)),
+		core.WithLeaseTimeout(leaseTimeout),
+		core.WithRateLimiter(newRateLimiter()),
+		core.WithBreakers(newBreakers()),
+		core.WithQuotas(newQuotas()),
+	)
+	if key := os.Getenv("ADMIN_API_KEY"); key != "" {
+		if err := app.EnsureAdminKey(key); err != nil {
+			log.Fatal("Invalid ADMIN_API_KEY: ", err)
+		}
+	}
 	app.Initworkers(100)
-	handlers := NewHandlers(app)
 
 	logSettings()
 
 	gin.SetMode(gin.ReleaseMode)
+	srv := &http.Server{
+		Addr:    "0.0.0.0:8080",
+		Handler: newRouter(app, newTokenVerifier()),
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server error: ", err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	shutdown(srv, app, storage)
+}
+
+func newRouter(app *core.App, verifier auth.TokenVerifier) *gin.Engine {
+	handlers := NewHandlers(app)
 	router := gin.Default()
 	router.GET("/api/v1/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	router.POST("/api/v1/tasks", handlers.createTask)
-	router.GET("/api/v1/tasks", handlers.getTasks)
-	router.DELETE("/api/v1/tasks", handlers.deleteTasks)
-	router.GET("/api/v1/tasks/:id", handlers.getTaskById)
-	router.DELETE("/api/v1/tasks/:id", handlers.deleteTaskById)
+	// Every endpoint but the documentation needs an API key or a token; its
+	// scopes decide what it may do.
+	api := router.Group("/api/v1", auth.Middleware(app, verifier))
+	read := api.Group("", auth.RequireScope(model.ScopeTasksRead))
+	write := api.Group("", auth.RequireScope(model.ScopeTasksWrite))
+	admin := api.Group("", auth.RequireScope(model.ScopeTasksAdmin))
+	deployment := api.Group("", auth.RequireScope(model.ScopeDeploymentAdmin))
+
+	write.POST("/tasks", handlers.createTask)
+	write.POST("/tasks/batch", handlers.createTasksBatch)
+	read.GET("/tasks", handlers.getTasks)
+	read.GET("/tasks/events", handlers.streamTaskEvents)
+	admin.DELETE("/tasks", handlers.deleteTasks)
+	read.GET("/tasks/:id", handlers.getTaskById)
+	write.DELETE("/tasks/:id", handlers.deleteTaskById)
+	write.POST("/tasks/:id/cancel", handlers.cancelTask)
+	read.GET("/tasks/:id/attempts", handlers.getTaskAttempts)
+	read.GET("/tasks/:id/transitions", handlers.getTaskTransitions)
+	read.GET("/tasks/:id/deliveries", handlers.getTaskDeliveries)
+
+	write.POST("/schedules", handlers.createSchedule)
+	read.GET("/schedules", handlers.getSchedules)
+	read.GET("/schedules/:id", handlers.getScheduleById)
+	write.PUT("/schedules/:id", handlers.updateSchedule)
+	write.DELETE("/schedules/:id", handlers.deleteScheduleById)
+	read.GET("/schedules/:id/tasks", handlers.getScheduleTasks)
 
-	router.Run("0.0.0.0:8080")
+	deployment.GET("/admin/ratelimits", handlers.getRateLimits)
+	deployment.PUT("/admin/ratelimits/:pattern", handlers.setRateLimit)
+	deployment.DELETE("/admin/ratelimits/:pattern", handlers.deleteRateLimit)
+	deployment.GET("/admin/breakers", handlers.getBreakers)
+	deployment.GET("/admin/quotas", handlers.getQuotas)
+	deployment.PUT("/admin/quotas/:tenant", handlers.setQuota)
+	deployment.DELETE("/admin/quotas/:tenant", handlers.deleteQuota)
+	// The API keys of a tenant are managed by its admins.
+	admin.POST("/admin/apikeys", handlers.issueAPIKey)
+	admin.GET("/admin/apikeys", handlers.getAPIKeys)
+	admin.DELETE("/admin/apikeys/:id", handlers.revokeAPIKey)
+	return router
+}
+
+// shutdown stops the service within SHUTDOWN_TIMEOUT. Task creation is
+// refused while the workers drain, so the API keeps answering reads until the
+// HTTP server itself is stopped.
+func shutdown(srv *http.Server, app *core.App, storage *postgres.PostgreSQLStorage) {
+	timeout, err := time.ParseDuration(postgres.GetEnv("SHUTDOWN_TIMEOUT", "30s"))
+	if err != nil {
+		log.Printf("Invalid SHUTDOWN_TIMEOUT, using 30s: %v\n", err)
+		timeout = 30 * time.Second
+	}
+	log.Printf("Shutting down, waiting up to %s for in-flight tasks\n", timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := app.Shutdown(ctx); err != nil {
+		log.Printf("Workers did not finish in time: %v\n", err)
+	}
+	// The HTTP server gets a short grace period of its own, so that
+	// requests are not cut off when the workers used up the deadline.
+	httpCtx, httpCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer httpCancel()
+	if err := srv.Shutdown(httpCtx); err != nil {
+		log.Printf("Error shutting down the HTTP server: %v\n", err)
+	}
+	if err := storage.Close(); err != nil {
+		log.Printf("Error closing the database: %v\n", err)
+	}
+	log.Println("Shutdown complete")
+}
+
+// newQueue picks the task queue named by QUEUE_BACKEND: "postgres" (default)
+// keeps pending tasks in the database, "memory" in memory. Both dequeue tasks
+// by priority, and QUEUE_PRIORITY_AGING sets how long a task has to wait to
+// gain one priority level.
+func newQueue(db *sql.DB, leaseTimeout time.Duration) queue.TaskQueue {
+	aging, err := time.ParseDuration(postgres.GetEnv("QUEUE_PRIORITY_AGING", "30s"))
+	if err != nil {
+		log.Fatal("Invalid QUEUE_PRIORITY_AGING: ", err)
+	}
+	switch backend := postgres.GetEnv("QUEUE_BACKEND", "postgres"); backend {
+	case "memory":
+		return queue.NewPriorityQueue(100, aging)
+	case "postgres":
+		pollInterval, err := time.ParseDuration(postgres.GetEnv("QUEUE_POLL_INTERVAL", "1s"))
+		if err != nil {
+			log.Fatal("Invalid QUEUE_POLL_INTERVAL: ", err)
+		}
+		return postgres.NewPostgreSQLQueue(db, pollInterval, aging, leaseTimeout)
+	default:
+		log.Fatalf("Unknown QUEUE_BACKEND %q", backend)
+		return nil
+	}
+}
+
+// leaseTimeout reads LEASE_TIMEOUT, the time after which a task in process
+// whose worker stopped sending heartbeats is recovered.
+func leaseTimeout() time.Duration {
+	timeout, err := time.ParseDuration(postgres.GetEnv("LEASE_TIMEOUT", "30s"))
+	if err != nil || timeout <= 0 {
+		log.Fatal("Invalid LEASE_TIMEOUT: ", postgres.GetEnv("LEASE_TIMEOUT", "30s"))
+	}
+	return timeout
+}
+
+// maxWait bounds the wait parameter, so that long polls end well before the
+// timeouts of proxies in front of the service.
+const maxWait = 60 * time.Second
+
+// parseWait reads the wait parameter of the long-polling requests.
+func parseWait(c *gin.Context) (time.Duration, error) {
+	value := c.Query("wait")
+	if value == "" {
+		return 0, nil
+	}
+	wait, err := time.ParseDuration(value)
+	if err != nil || wait < 0 || wait > maxWait {
+		return 0, fmt.Errorf("wait must be a duration between 0s and %s", maxWait)
+	}
+	return wait, nil
+}
+
+// creationErrorStatus maps an error of task creation to its HTTP status.
+func creationErrorStatus(err error) int {
+	if errors.Is(err, core.ErrShuttingDown) {
+		return http.StatusServiceUnavailable
+	}
+	if errors.Is(err, core.ErrQuotaExceeded) {
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
 }
 
 func logSettings() {
@@ -60,15 +224,23 @@ func logSettings() {
 }
 
 // @Tags Tasks
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /api/v1/task [post]
 // @OperationId createTask
 // @Param task body model.Task true "Task object"
+// @Param Idempotency-Key header string false "Key that makes a retried request return the original task"
+// @Param wait query string false "Time to wait for the task to finish, at most 60s; the task is returned instead of its ID" example(30s)
 // @Summary Create a new task and send it to a third party service
-// @Description Creates a new HTTP task
+// @Description Creates a new HTTP task. A repeat of a request with the same Idempotency-Key returns the ID of the original task. With wait the response is held until the task is done, failed or cancelled, or the wait expires.
 // @Accept json
 // @Produce json
 // @Success 201 {object} map[string]int64
+// @Success 200 {object} map[string]int64 "Task created by an earlier request with the same Idempotency-Key"
 // @Failure 400 {string} string "Bad request"
+// @Failure 409 {string} string "Idempotency-Key reused with a different payload"
+// @Failure 429 {string} string "Queue-depth quota of the tenant exceeded"
+// @Failure 503 {string} string "Service is shutting down"
 // @Failure 500 {string} string "Internal server error"
 func (h *Handlers) createTask(c *gin.Context) {
 	log.Println("Это реально новый код!")
@@ -77,35 +249,78 @@ func (h *Handlers) createTask(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	task.ResetServerFields()
+	task.CreatedBy = auth.SubjectFrom(c)
+	task.Tenant = auth.TenantFrom(c)
+	if err := task.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	wait, err := parseWait(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if key := c.GetHeader("Idempotency-Key"); key != "" {
+		h.createTaskIdempotent(c, task, key, wait)
+		return
+	}
 
 	id, err := h.core.CreateTask(task)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(creationErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"id": id,
-	})
+	h.respondCreated(c, http.StatusCreated, id, wait)
 }
 
-// @Router /api/v1/tasks [get]
-// @OperationId getTasks
-// @Summary Get all tasks
-// @Description Returns list of all tasks
-// @Produce json
-// @Success 200 {array} model.Task
-// @Failure 500 {string} string "Internal server error"
-func (h *Handlers) getTasks(c *gin.Context) {
-	tasks, err := h.core.GetAllTasks()
+// createTaskIdempotent answers a repeated request with the ID of the task
+// created by the first one.
+func (h *Handlers) createTaskIdempotent(c *gin.Context, task model.Task, key string, wait time.Duration) {
+	if len(key) > maxIdempotencyKeyLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+		return
+	}
+
+	id, created, err := h.core.CreateTaskIdempotent(task, key)
+	if errors.Is(err, core.ErrIdempotencyConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(creationErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, tasks)
+	status := http.StatusCreated
+	if !created {
+		status = http.StatusOK
+	}
+	h.respondCreated(c, status, id, wait)
+}
+
+// respondCreated answers with the ID of the created task, or with the task
+// itself once it finishes when the request asked to wait.
+func (h *Handlers) respondCreated(c *gin.Context, status int, id int64, wait time.Duration) {
+	if wait == 0 {
+		c.JSON(status, gin.H{
+			"id": id,
+		})
+		return
+	}
+
+	task, err := h.core.WaitForTask(c.Request.Context(), auth.TenantFrom(c), id, wait)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(status, task)
 }
 
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /api/v1/tasks [delete]
 // @OperationId deleteTasks
 // @Summary Delete all tasks
@@ -113,7 +328,7 @@ func (h *Handlers) getTasks(c *gin.Context) {
 // @Success 204 "No Content"
 // @Failure 500 {string} string "Internal server error"
 func (h *Handlers) deleteTasks(c *gin.Context) {
-	err := h.core.CleanStorage()
+	err := h.core.CleanStorage(auth.TenantFrom(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -122,11 +337,14 @@ func (h *Handlers) deleteTasks(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /api/v1/tasks/{id} [get]
 // @OperationId getTaskById
 // @Param id path int true "Task ID"
+// @Param wait query string false "Time to wait for the task to finish, at most 60s" example(30s)
 // @Summary Get task by ID
-// @Description Returns single task by ID
+// @Description Returns single task by ID. With wait the response is held until the task is done, failed or cancelled, or the wait expires.
 // @Produce json
 // @Success 200 {object} model.Task
 // @Failure 404 {string} string "Task not found"
@@ -138,8 +356,13 @@ func (h *Handlers) getTaskById(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID is not integer!"})
 		return
 	}
+	wait, err := parseWait(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	task, err := h.core.GetTaskByID(id)
+	task, err := h.core.WaitForTask(c.Request.Context(), auth.TenantFrom(c), id, wait)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
@@ -152,6 +375,41 @@ func (h *Handlers) getTaskById(c *gin.Context) {
 	c.JSON(http.StatusOK, task)
 }
 
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/tasks/{id}/cancel [post]
+// @OperationId cancelTask
+// @Param id path int true "Task ID"
+// @Summary Cancel task
+// @Description Cancels a queued, scheduled or running task; a running task has its request aborted
+// @Produce json
+// @Success 200 {object} model.Task
+// @Failure 404 {string} string "Task not found"
+// @Failure 409 {string} string "Task has already finished"
+// @Failure 500 {string} string "Internal server error"
+func (h *Handlers) cancelTask(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID is not integer!"})
+		return
+	}
+
+	task, err := h.core.CancelTask(auth.TenantFrom(c), id)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, task)
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+	case errors.Is(err, core.ErrTaskFinished):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": task.Status})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /api/v1/tasks/{id} [delete]
 // @OperationId deleteTaskById
 // @Summary Delete task
@@ -168,7 +426,7 @@ func (h *Handlers) deleteTaskById(c *gin.Context) {
 		return
 	}
 
-	status, err := h.core.DeleteTaskByID(id)
+	status, err := h.core.DeleteTaskByID(auth.TenantFrom(c), id)
 	switch status {
 	case http.StatusNotFound:
 		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
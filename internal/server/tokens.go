@@ -0,0 +1,33 @@
+package server
+
+import (
+	"MyFirstGoApp/internal/auth"
+	"MyFirstGoApp/internal/postgres"
+	"log"
+	"os"
+	"time"
+)
+
+// newTokenVerifier configures the JWT bearer tokens accepted besides API
+// keys. Tokens are accepted only when JWT_JWKS names a JWKS file or URL.
+func newTokenVerifier() auth.TokenVerifier {
+	source := os.Getenv("JWT_JWKS")
+	if source == "" {
+		return nil
+	}
+	refresh, err := time.ParseDuration(postgres.GetEnv("JWT_JWKS_REFRESH", "5m"))
+	if err != nil || refresh <= 0 {
+		log.Fatal("Invalid JWT_JWKS_REFRESH: ", postgres.GetEnv("JWT_JWKS_REFRESH", "5m"))
+	}
+	keys, err := auth.NewJWKS(source, refresh)
+	if err != nil {
+		log.Fatal("Loading JWT_JWKS: ", err)
+	}
+	return auth.NewJWTVerifier(keys, auth.JWTConfig{
+		Issuer:       os.Getenv("JWT_ISSUER"),
+		Audience:     os.Getenv("JWT_AUDIENCE"),
+		SubjectClaim: os.Getenv("JWT_SUBJECT_CLAIM"),
+		ScopeClaim:   os.Getenv("JWT_SCOPE_CLAIM"),
+		TenantClaim:  os.Getenv("JWT_TENANT_CLAIM"),
+	})
+}
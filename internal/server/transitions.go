@@ -0,0 +1,43 @@
+package server
+
+import (
+	"MyFirstGoApp/internal/auth"
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Tags Tasks
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/tasks/{id}/transitions [get]
+// @OperationId getTaskTransitions
+// @Param id path int true "Task ID"
+// @Summary Get status history of a task
+// @Description Returns every status change of a task with its time, starting with the status the task was created with
+// @Produce json
+// @Success 200 {array} model.TaskTransition
+// @Failure 404 {string} string "Task not found"
+// @Failure 500 {string} string "Internal server error"
+func (h *Handlers) getTaskTransitions(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID is not integer!"})
+		return
+	}
+
+	transitions, err := h.core.GetTaskTransitions(auth.TenantFrom(c), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, transitions)
+}
@@ -0,0 +1,22 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWait(t *testing.T) {
+	wait, err := parseWait(queryContext("wait=30s"))
+	if err != nil || wait != 30*time.Second {
+		t.Errorf("Expected 30s, got %s, %v", wait, err)
+	}
+	wait, err = parseWait(queryContext(""))
+	if err != nil || wait != 0 {
+		t.Errorf("Expected no wait, got %s, %v", wait, err)
+	}
+	for _, rawQuery := range []string{"wait=soon", "wait=-1s", "wait=2m"} {
+		if _, err := parseWait(queryContext(rawQuery)); err == nil {
+			t.Errorf("Expected error for %q, got nil", rawQuery)
+		}
+	}
+}
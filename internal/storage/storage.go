@@ -2,14 +2,92 @@ package storage
 
 import (
 	"MyFirstGoApp/internal/model"
+	"errors"
+	"time"
 )
 
+// ErrDuplicateKey is returned when a task with the same idempotency key is
+// already stored.
+var ErrDuplicateKey = errors.New("duplicate idempotency key")
+
+// Sort orders of QueryTasks. The task ID breaks ties in all of them.
+const (
+	SortByID        = "id"
+	SortByCreatedAt = "created_at"
+	SortByPriority  = "priority"
+)
+
+// TaskQuery selects one page of the tasks of a tenant. Empty fields other
+// than Tenant do not filter.
+type TaskQuery struct {
+	Tenant    string
+	Statuses  []string
+	Method    string
+	Host      string
+	URLPrefix string
+	// Tags selects the tasks that have all of the tags.
+	Tags          []string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	SortBy        string
+	Descending    bool
+	// After continues the listing behind the last task of the previous page.
+	After *TaskCursor
+	Limit int
+}
+
+// TaskCursor is the position of a task in the sort order of a TaskQuery.
+type TaskCursor struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Priority  int       `json:"priority,omitempty"`
+}
+
+// Storage keeps the tasks, schedules and API keys. The methods that take a
+// tenant, or a task or schedule carrying one, serve API requests and see only
+// the data of that tenant. The others are used by the workers, the reaper and
+// the scheduler, which work for every tenant and address tasks they already
+// hold by ID.
 type Storage interface {
 	AddTask(task model.Task) (int64, error)
-	GetAllTasks() ([]model.Task, error)
-	GetTaskByID(id int64) (model.Task, error)
-	DeleteTaskByID(id int64) (int64, error)
+	AddTasks(tasks []model.Task) ([]int64, error)
+	QueryTasks(query TaskQuery) ([]model.Task, error)
+	GetTaskByID(tenant string, id int64) (model.Task, error)
+	GetTaskByIdempotencyKey(tenant string, key string) (model.Task, error)
+	GetTasksBySchedule(tenant string, scheduleID int64) ([]model.Task, error)
+	CountUnfinishedTasks(tenant string) (int, error)
+	DeleteTaskByID(tenant string, id int64) (int64, error)
+	CleanStorage(tenant string) error
+	GetTasksByStatus(status string) ([]model.Task, error)
 	UpdateTaskStatus(task *model.Task, status string) error
+	UpdateTaskStatusIf(task *model.Task, current string, status string) (bool, error)
 	UpdateTaskResponse(task *model.Task, response *model.ResponseData) error
-	CleanStorage() error
+	UpdateTaskAttempts(task *model.Task) error
+	UpdateTaskAssertionResults(task *model.Task) error
+	UpdateTaskLease(task *model.Task, owner string, expiresAt time.Time) error
+	ExtendTaskLeases(owner string, ids []int64, expiresAt time.Time) error
+	GetExpiredTasks(now time.Time) ([]model.Task, error)
+	ReclaimTask(task *model.Task, status string) (bool, error)
+	GetPendingTasks() ([]model.Task, error)
+
+	AddTaskAttempt(attempt model.TaskAttempt) error
+	GetTaskAttempts(tenant string, taskID int64) ([]model.TaskAttempt, error)
+	GetTaskTransitions(tenant string, taskID int64) ([]model.TaskTransition, error)
+
+	AddSchedule(schedule model.Schedule) (int64, error)
+	GetAllSchedules(tenant string) ([]model.Schedule, error)
+	GetScheduleByID(tenant string, id int64) (model.Schedule, error)
+	UpdateSchedule(schedule model.Schedule) error
+	DeleteScheduleByID(tenant string, id int64) error
+	GetDueSchedules(now time.Time) ([]model.Schedule, error)
+	ClaimScheduleRun(id int64, prevRunAt time.Time, nextRunAt time.Time) (bool, error)
+	SetScheduleError(id int64, message string) error
+
+	AddWebhookDelivery(delivery model.WebhookDelivery) error
+	GetWebhookDeliveries(tenant string, taskID int64) ([]model.WebhookDelivery, error)
+
+	AddAPIKey(key model.APIKey) (int64, error)
+	GetAPIKeyByHash(hash string) (model.APIKey, error)
+	GetAllAPIKeys(tenant string) ([]model.APIKey, error)
+	RevokeAPIKey(tenant string, id int64, revokedAt time.Time) error
 }
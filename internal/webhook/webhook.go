@@ -0,0 +1,111 @@
+package webhook
+
+import (
+	"MyFirstGoApp/internal/model"
+	"MyFirstGoApp/internal/retry"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const DefaultTimeout = 10 * time.Second
+
+// DefaultPolicy is the retry policy of webhook deliveries: five attempts,
+// backing off from one second to one minute.
+var DefaultPolicy = model.RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   model.Duration(time.Second),
+	MaxDelay:    model.Duration(time.Minute),
+	Jitter:      0.2,
+}
+
+// Notifier POSTs finished tasks to their callback URL and reports every
+// attempt to record.
+type Notifier struct {
+	client *http.Client
+	policy model.RetryPolicy
+	record func(delivery model.WebhookDelivery)
+}
+
+func NewNotifier(policy model.RetryPolicy, record func(delivery model.WebhookDelivery)) *Notifier {
+	return &Notifier{
+		client: &http.Client{Timeout: DefaultTimeout},
+		policy: policy,
+		record: record,
+	}
+}
+
+// Deliver sends the task to its callback URL until the callback answers with
+// a 2xx status, the attempts of the policy run out or ctx is done. It reports
+// whether the webhook was delivered.
+func (n *Notifier) Deliver(ctx context.Context, task model.Task) bool {
+	payload, err := json.Marshal(task)
+	if err != nil {
+		n.report(task, 1, 0, fmt.Errorf("encoding task: %w", err))
+		return false
+	}
+
+	for attempt := 1; ; attempt++ {
+		statusCode, err := n.send(ctx, task, payload, attempt)
+		if err == nil && (statusCode < 200 || statusCode > 299) {
+			err = fmt.Errorf("callback answered with status %d", statusCode)
+		}
+		n.report(task, attempt, statusCode, err)
+		if err == nil {
+			return true
+		}
+		if attempt >= n.policy.MaxAttempts {
+			return false
+		}
+
+		timer := time.NewTimer(retry.Delay(&n.policy, attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+	}
+}
+
+func (n *Notifier) send(ctx context.Context, task model.Task, payload []byte, attempt int) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, task.CallbackURL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Task-ID", strconv.FormatInt(task.ID, 10))
+	req.Header.Set("X-Webhook-Attempt", strconv.Itoa(attempt))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+func (n *Notifier) report(task model.Task, attempt int, statusCode int, err error) {
+	if n.record == nil {
+		return
+	}
+	delivery := model.WebhookDelivery{
+		TaskID:     task.ID,
+		URL:        task.CallbackURL,
+		Status:     task.Status,
+		Attempt:    attempt,
+		StatusCode: statusCode,
+		Success:    err == nil,
+		CreatedAt:  time.Now(),
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	n.record(delivery)
+}
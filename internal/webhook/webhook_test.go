@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"MyFirstGoApp/internal/model"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDeliverRetriesUntilSuccess(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var task model.Task
+		if err := json.NewDecoder(r.Body).Decode(&task); err != nil || task.ID != 3 {
+			t.Errorf("Unexpected payload %+v, err %v", task, err)
+		}
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	var deliveries []model.WebhookDelivery
+	policy := model.RetryPolicy{MaxAttempts: 5, BaseDelay: model.Duration(time.Millisecond)}
+	notifier := NewNotifier(policy, func(delivery model.WebhookDelivery) {
+		deliveries = append(deliveries, delivery)
+	})
+
+	task := model.Task{ID: 3, Status: model.Done, CallbackURL: server.URL}
+	if !notifier.Deliver(context.Background(), task) {
+		t.Fatal("Expected the webhook to be delivered")
+	}
+	if len(deliveries) != 3 {
+		t.Fatalf("Expected 3 recorded attempts, got %d", len(deliveries))
+	}
+	if deliveries[0].Success || deliveries[0].StatusCode != http.StatusServiceUnavailable || deliveries[0].Error == "" {
+		t.Errorf("Unexpected first attempt %+v", deliveries[0])
+	}
+	if !deliveries[2].Success || deliveries[2].Attempt != 3 || deliveries[2].Status != model.Done {
+		t.Errorf("Unexpected last attempt %+v", deliveries[2])
+	}
+}
+
+func TestDeliverGivesUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	attempts := 0
+	policy := model.RetryPolicy{MaxAttempts: 2, BaseDelay: model.Duration(time.Millisecond)}
+	notifier := NewNotifier(policy, func(delivery model.WebhookDelivery) {
+		attempts++
+	})
+
+	if notifier.Deliver(context.Background(), model.Task{ID: 1, CallbackURL: server.URL}) {
+		t.Error("Expected the delivery to fail")
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+}